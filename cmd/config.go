@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/ui"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Manage the config file",
+}
+
+var configPathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the path of the config file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Println(config.Path())
+	},
+}
+
+var configInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Write a config file with the defaults and its JSON Schema",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		force, err := cmd.Flags().GetBool("force")
+		if err != nil {
+			log.Fatal("Cannot parse the force flag", err)
+		}
+		path, err := initConfig(force)
+		if err != nil {
+			fmt.Println("Error writing the config:", err)
+			os.Exit(1)
+		}
+		fmt.Println("Wrote", path)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for errors",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := loadConfig(nil); err != nil {
+			fmt.Println("Error in config", err)
+			os.Exit(1)
+		}
+		fmt.Println(config.Path(), "is valid")
+	},
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema of the config file",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		schema, err := schemaJSON()
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(schema))
+		return nil
+	},
+}
+
+func init() {
+	configInitCmd.Flags().BoolP("force", "f", false, "Overwrite an existing config file")
+	configCmd.AddCommand(configPathCmd, configInitCmd, configValidateCmd, configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// loadConfig reads the config files, applies the flag overrides and checks
+// the sections validated by the packages using them.
+func loadConfig(overrides map[string]string) (config.Config, error) {
+	cfg, err := config.Load(utils.GitRepoRoot())
+	if err != nil {
+		return cfg, err
+	}
+	if err := cfg.Override(overrides); err != nil {
+		return cfg, fmt.Errorf("flags:\n%w", err)
+	}
+
+	var errs []error
+	if _, err := ui.NewKeyMap(cfg.Keys); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := theme.New(cfg.Theme, true); err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return cfg, fmt.Errorf("config:\n%w", errors.Join(errs...))
+	}
+	return cfg, nil
+}
+
+// flagShorthands holds the shorthands of the flags overriding ui fields.
+var flagShorthands = map[string]string{
+	"side-by-side": "s",
+}
+
+// addUIFlags adds a flag for each field of the ui section.
+func addUIFlags(flags *pflag.FlagSet) {
+	for _, f := range config.UIFields() {
+		short := flagShorthands[f.Flag]
+		switch f.Kind {
+		case reflect.Bool:
+			flags.BoolP(f.Flag, short, false, f.Doc)
+		case reflect.Int:
+			flags.IntP(f.Flag, short, 0, f.Doc)
+		case reflect.Slice:
+			flags.StringSliceP(f.Flag, short, nil, f.Doc)
+		default:
+			flags.StringP(f.Flag, short, "", f.Doc)
+		}
+	}
+}
+
+// uiFlagValues returns the values of the ui flags that were set, by field
+// path.
+func uiFlagValues(flags *pflag.FlagSet) map[string]string {
+	values := map[string]string{}
+	for _, f := range config.UIFields() {
+		flag := flags.Lookup(f.Flag)
+		if flag == nil || !flag.Changed {
+			continue
+		}
+		if f.Kind == reflect.Slice {
+			list, _ := flags.GetStringSlice(f.Flag)
+			values[f.Path] = strings.Join(list, ",")
+			continue
+		}
+		values[f.Path] = flag.Value.String()
+	}
+	return values
+}
+
+func schemaJSON() ([]byte, error) {
+	return json.MarshalIndent(config.Schema(), "", "  ")
+}
+
+// initConfig writes the default config file and its schema, and returns the
+// path of the config file.
+func initConfig(force bool) (string, error) {
+	path := config.Path()
+	if path == "" {
+		return "", errors.New("cannot find a config directory")
+	}
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+
+	data, err := config.DefaultFile()
+	if err != nil {
+		return "", err
+	}
+	schema, err := schemaJSON()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	schemaPath := filepath.Join(filepath.Dir(path), config.SchemaFileName)
+	if err := os.WriteFile(schemaPath, schema, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
@@ -21,7 +21,9 @@ import (
 	"github.com/muesli/termenv"
 
 	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/theme"
 	"github.com/dlvhdr/diffnav/pkg/ui"
+	"github.com/dlvhdr/diffnav/pkg/utils"
 	"github.com/dlvhdr/diffnav/pkg/version"
 )
 
@@ -75,7 +77,7 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.Flags().BoolP("side-by-side", "s", false, "Force side-by-side diff view")
+	addUIFlags(rootCmd.Flags())
 
 	rootCmd.Flags().BoolP("unified", "u", false, "Force unified diff view")
 
@@ -83,10 +85,6 @@ func init() {
 
 	rootCmd.Run = func(cmd *cobra.Command, args []string) {
 		// Parse CLI flags
-		sideBySideFlag, err := cmd.Flags().GetBool("side-by-side")
-		if err != nil {
-			log.Fatal("Cannot parse the side-by-side flag", err)
-		}
 		unifiedFlag, err := cmd.Flags().GetBool("unified")
 		if err != nil {
 			log.Fatal("Cannot parse the unified flag", err)
@@ -164,20 +162,48 @@ func init() {
 			fmt.Println("No input provided, exiting")
 			os.Exit(0)
 		}
-		cfg := config.Load()
-
-		// Override config with CLI flags if specified
+		// Flags override the config files and the environment.
+		overrides := uiFlagValues(cmd.Flags())
 		if unifiedFlag {
-			cfg.UI.SideBySide = false
-		} else if sideBySideFlag {
-			cfg.UI.SideBySide = true
+			overrides["ui.sideBySide"] = "false"
+		}
+		// Picking a view mode turns the auto mode off, unless it's set too.
+		if _, ok := overrides["ui.sideBySide"]; ok {
+			if _, ok := overrides["ui.autoSideBySide"]; !ok {
+				overrides["ui.autoSideBySide"] = "false"
+			}
+		}
+		cfg, err := loadConfig(overrides)
+		if err != nil {
+			fmt.Println("Error in config", err)
+			os.Exit(1)
 		}
 
-		ttyIn, _, err := tea.OpenTTY()
+		ttyIn, ttyOut, err := tea.OpenTTY()
 		if err != nil {
 			log.Fatal(err)
 		}
-		p := tea.NewProgram(ui.New(input, cfg), tea.WithInput(ttyIn))
+
+		hasDarkBackground := true
+		if cfg.Theme.Name == "" || cfg.Theme.Name == theme.Auto {
+			hasDarkBackground = lipgloss.HasDarkBackground(ttyIn, ttyOut)
+		}
+		th, err := theme.New(cfg.Theme, hasDarkBackground)
+		if err != nil {
+			log.Fatal(err)
+		}
+		theme.Set(th)
+
+		m := ui.New(input, cfg)
+		m.WatchConfig(config.Paths(utils.GitRepoRoot()), func() (config.Config, theme.Theme, error) {
+			cfg, err := loadConfig(overrides)
+			if err != nil {
+				return cfg, theme.Theme{}, err
+			}
+			th, err := theme.New(cfg.Theme, hasDarkBackground)
+			return cfg, th, err
+		})
+		p := tea.NewProgram(m, tea.WithInput(ttyIn))
 
 		if _, err := p.Run(); err != nil {
 			log.Fatal(err)
@@ -0,0 +1,130 @@
+// Package codeowners reads a repository's CODEOWNERS file and resolves the
+// owners of changed files.
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// Unowned is the group name of files that no rule assigns owners to.
+const Unowned = "(unowned)"
+
+// Locations are the paths, relative to the repository root, searched for a
+// CODEOWNERS file in the order GitHub uses.
+var Locations = []string{
+	filepath.Join(".github", "CODEOWNERS"),
+	"CODEOWNERS",
+	filepath.Join("docs", "CODEOWNERS"),
+}
+
+type rule struct {
+	pattern string
+	owners  []string
+}
+
+// Owners maps file paths to their owners. The zero value has no rules.
+type Owners struct {
+	// Path is the CODEOWNERS file the rules were read from, if any.
+	Path  string
+	rules []rule
+}
+
+// Load reads the first CODEOWNERS file found under root. A missing file
+// results in an Owners without rules.
+func Load(root string) Owners {
+	if root == "" {
+		return Owners{}
+	}
+	for _, location := range Locations {
+		path := filepath.Join(root, location)
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		return Owners{Path: path, rules: parse(f)}
+	}
+	return Owners{}
+}
+
+// Parse reads CODEOWNERS rules from r.
+func Parse(r io.Reader) Owners {
+	return Owners{rules: parse(r)}
+}
+
+func parse(r io.Reader) []rule {
+	var rules []rule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, " #"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		// A pattern without owners un-assigns the files it matches.
+		rules = append(rules, rule{pattern: fields[0], owners: fields[1:]})
+	}
+	return rules
+}
+
+// HasRules returns whether any ownership rules were loaded.
+func (o Owners) HasRules() bool {
+	return len(o.rules) > 0
+}
+
+// Of returns the owners of the file at path. As in GitHub, the last matching
+// rule wins.
+func (o Owners) Of(path string) []string {
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if match(o.rules[i].pattern, path) {
+			return o.rules[i].owners
+		}
+	}
+	return nil
+}
+
+// match reports whether the CODEOWNERS pattern matches path. Patterns that
+// name a directory match everything below it, with or without a trailing
+// slash.
+func match(pattern, path string) bool {
+	if utils.MatchGlob(pattern, path) {
+		return true
+	}
+	if strings.HasSuffix(pattern, "/") || strings.HasSuffix(pattern, "*") {
+		return false
+	}
+	return utils.MatchGlob(pattern+"/", path)
+}
+
+// Group returns the name of the group a file with the given owners belongs
+// to when the tree is grouped by owner.
+func Group(owners []string) string {
+	if len(owners) == 0 {
+		return Unowned
+	}
+	return strings.Join(owners, " ")
+}
+
+// MatchesOwner reports whether any of owners contains query, ignoring case.
+// It backs the "owner:" search filter.
+func MatchesOwner(owners []string, query string) bool {
+	query = strings.ToLower(query)
+	if len(owners) == 0 {
+		return strings.Contains(strings.ToLower(Unowned), query)
+	}
+	for _, owner := range owners {
+		if strings.Contains(strings.ToLower(owner), query) {
+			return true
+		}
+	}
+	return false
+}
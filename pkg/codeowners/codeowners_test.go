@@ -0,0 +1,83 @@
+package codeowners
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testCodeowners = `# Default owners
+*                   @org/core
+
+*.md                @org/docs # inline comment
+/pkg/ui/            @org/frontend @alice
+pkg/config          @org/config
+docs/*              @org/docs
+/build/logs/
+`
+
+func TestOf(t *testing.T) {
+	owners := Parse(strings.NewReader(testCodeowners))
+
+	tests := []struct {
+		path     string
+		expected []string
+	}{
+		{"main.go", []string{"@org/core"}},
+		{"README.md", []string{"@org/docs"}},
+		{"pkg/ui/tui.go", []string{"@org/frontend", "@alice"}},
+		{"pkg/ui/README.md", []string{"@org/frontend", "@alice"}},
+		{"pkg/config/config.go", []string{"@org/config"}},
+		{"docs/setup.txt", []string{"@org/docs"}},
+		{"docs/deep/setup.txt", []string{"@org/core"}},
+		{"build/logs/out.txt", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := owners.Of(tt.path); !slices.Equal(got, tt.expected) {
+				t.Errorf("Of(%q) = %v, want %v", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadSearchesLocations(t *testing.T) {
+	root := t.TempDir()
+	if owners := Load(root); owners.HasRules() {
+		t.Fatalf("expected no rules without a CODEOWNERS file")
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "CODEOWNERS"), []byte("* @docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Load(root).Of("a.go"); !slices.Equal(got, []string{"@docs"}) {
+		t.Errorf("expected docs/CODEOWNERS to be used, got %v", got)
+	}
+
+	if err := os.MkdirAll(filepath.Join(root, ".github"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, ".github", "CODEOWNERS"), []byte("* @github\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := Load(root).Of("a.go"); !slices.Equal(got, []string{"@github"}) {
+		t.Errorf("expected .github/CODEOWNERS to take precedence, got %v", got)
+	}
+}
+
+func TestMatchesOwner(t *testing.T) {
+	if !MatchesOwner([]string{"@org/Frontend"}, "@org/front") {
+		t.Error("expected a case-insensitive partial match")
+	}
+	if MatchesOwner([]string{"@org/frontend"}, "@org/docs") {
+		t.Error("expected no match")
+	}
+	if !MatchesOwner(nil, "unowned") {
+		t.Error("expected files without owners to match \"unowned\"")
+	}
+}
@@ -0,0 +1,44 @@
+package config_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/editor"
+	"github.com/dlvhdr/diffnav/pkg/exclude"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/filesort"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/ui"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/filetree"
+)
+
+// The accepted values are listed in the config package, which the packages
+// defining them depend on.
+func TestChoicesMatchPackages(t *testing.T) {
+	statuses := []string{}
+	for _, s := range filenode.Statuses {
+		statuses = append(statuses, s.String())
+	}
+
+	tests := map[string][]string{
+		"ui.icons": {
+			filenode.IconsNerdStatus, filenode.IconsNerdSimple, filenode.IconsNerdFiletype,
+			filenode.IconsNerdFull, filenode.IconsUnicode, filenode.IconsASCII,
+		},
+		"ui.sort":           filesort.Modes,
+		"ui.fileTreeMode":   {filetree.ModeTree, filetree.ModeFlat},
+		"ui.layout":         ui.Layouts,
+		"ui.hiddenStatuses": statuses,
+		"ui.generated":      {exclude.GeneratedCollapse, exclude.GeneratedHide, exclude.GeneratedShow},
+		"theme.name":        theme.Names(),
+		"editor.preset":     editor.Names(),
+	}
+	for field, want := range tests {
+		got := config.Choices[field]
+		if !slices.Equal(slices.Sorted(slices.Values(got)), slices.Sorted(slices.Values(want))) {
+			t.Errorf("%s: config accepts %v, want %v", field, got, want)
+		}
+	}
+}
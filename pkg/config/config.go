@@ -9,37 +9,149 @@ import (
 )
 
 type UIConfig struct {
-	HideHeader      bool   `yaml:"hideHeader"`
-	HideFooter      bool   `yaml:"hideFooter"`
-	ShowFileTree    bool   `yaml:"showFileTree"`
-	FileTreeWidth   int    `yaml:"fileTreeWidth"`
-	SearchTreeWidth int    `yaml:"searchTreeWidth"`
-	Icons           string `yaml:"icons"`          // "nerd-fonts-status" (default), "nerd-fonts-simple", "nerd-fonts-filetype", "nerd-fonts-full", "unicode", "ascii"
-	ColorFileNames  bool   `yaml:"colorFileNames"` // Color filenames by git status (default: true)
-	ShowDiffStats   bool   `yaml:"showDiffStats"`  // Show the amount of lines added / removed next to the file
-	SideBySide      bool   `yaml:"sideBySide"`     // Side-by-side diff view (default: true)
+	HideHeader          bool   `yaml:"hideHeader"`
+	HideFooter          bool   `yaml:"hideFooter"`
+	ShowFileTree        bool   `yaml:"showFileTree"`
+	FileTreeWidth       int    `yaml:"fileTreeWidth"`
+	FileTreeHeight      int    `yaml:"fileTreeHeight"` // Height of the file tree when stacked above the diff
+	SearchTreeWidth     int    `yaml:"searchTreeWidth"`
+	Layout              string `yaml:"layout"`              // "left" (default), "right", "top", "auto"
+	AutoLayoutWidth     int    `yaml:"autoLayoutWidth"`     // Width below which the auto layout stacks the file tree above the diff
+	Icons               string `yaml:"icons"`               // "nerd-fonts-status" (default), "nerd-fonts-simple", "nerd-fonts-filetype", "nerd-fonts-full", "unicode", "ascii"
+	ColorFileNames      bool   `yaml:"colorFileNames"`      // Color filenames by git status (default: true)
+	ShowDiffStats       bool   `yaml:"showDiffStats"`       // Show the amount of lines added / removed next to the file
+	DirChurnBar         bool   `yaml:"dirChurnBar"`         // Show a bar with each directory's share of the changes
+	SkipViewed          bool   `yaml:"skipViewed"`          // Skip files marked as viewed when moving to the next/previous file
+	SideBySide          bool   `yaml:"sideBySide"`          // Side-by-side diff view (default: true)
+	AutoSideBySide      bool   `yaml:"autoSideBySide"`      // Pick side-by-side or unified from the width of the diff and each file's changes
+	AutoSideBySideWidth int    `yaml:"autoSideBySideWidth"` // Width of the diff from which the auto mode uses side-by-side
+	Sort                string `yaml:"sort"`                // "tree" (default), "path", "churn", "additions", "deletions", "status", "extension"
+	FileTreeMode        string `yaml:"fileTreeMode"`        // "tree" (default) or "flat"
+
+	HiddenStatuses []string `yaml:"hiddenStatuses"` // Statuses hidden on startup: "added", "modified", "deleted", "renamed", "binary"
+	Exclude        []string `yaml:"exclude"`        // Glob patterns of files to hide
+	Collapse       []string `yaml:"collapse"`       // Glob patterns of files to move into the collapsed "generated" group
+	Generated      string   `yaml:"generated"`      // What to do with detected generated files: "collapse" (default), "hide", "show"
+
+	ReviewOrder ReviewOrderConfig `yaml:"reviewOrder"`
+}
+
+// ReviewOrderConfig holds glob patterns for files that should always be
+// listed before or after the rest, regardless of the sort mode.
+type ReviewOrderConfig struct {
+	First []string `yaml:"first"`
+	Last  []string `yaml:"last"`
+}
+
+// KeysConfig rebinds actions, mapping action names such as "nextFile" or
+// "filter.added" to the keys that trigger them. An empty list unbinds the
+// action.
+type KeysConfig map[string]KeyList
+
+// KeyList is a list of keys, e.g. ["ctrl+d", "J"]. A single key may be
+// written as a plain string.
+type KeyList []string
+
+// UnmarshalYAML accepts both a single key and a list of keys.
+func (k *KeyList) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var key string
+		if err := value.Decode(&key); err != nil {
+			return err
+		}
+		*k = KeyList{key}
+		return nil
+	}
+	var keys []string
+	if err := value.Decode(&keys); err != nil {
+		return err
+	}
+	*k = keys
+	return nil
+}
+
+// ThemeConfig selects the colors of the UI.
+type ThemeConfig struct {
+	Name   string            `yaml:"name"`   // "auto" (default), "dark", "light", "high-contrast"
+	Colors map[string]string `yaml:"colors"` // Overrides of the theme's named colors, e.g. accent: "#7aa2f7"
+}
+
+// EditorConfig selects the command opening files in an editor. Without
+// either field, $EDITOR is used.
+type EditorConfig struct {
+	Command string `yaml:"command"` // Template of the command, e.g. "nvim +{{.Line}} {{.Path}}"
+	Preset  string `yaml:"preset"`  // "vim", "nvim", "helix", "vscode" or "emacs"
+}
+
+// RendererConfig holds the options of the program rendering the diffs.
+type RendererConfig struct {
+	Delta DeltaConfig `yaml:"delta"`
+}
+
+// DeltaConfig holds options passed to delta, after the ones diffnav sets.
+// The options of the current view mode and preset are added to the common
+// ones.
+type DeltaConfig struct {
+	Args       []string                `yaml:"args"`       // e.g. ["--line-numbers", "--syntax-theme=Nord"]
+	Features   string                  `yaml:"features"`   // Space separated delta features, e.g. "decorations"
+	Unified    DeltaOptions            `yaml:"unified"`    // Options of the unified view
+	SideBySide DeltaOptions            `yaml:"sideBySide"` // Options of the side-by-side view
+	Presets    map[string]DeltaOptions `yaml:"presets"`    // Named options cycled through at runtime
+}
+
+// DeltaOptions is a set of delta args and features.
+type DeltaOptions struct {
+	Args     []string `yaml:"args"`
+	Features string   `yaml:"features"`
 }
 
 type Config struct {
-	UI UIConfig `yaml:"ui"`
+	UI       UIConfig       `yaml:"ui"`
+	Keys     KeysConfig     `yaml:"keys"`
+	Theme    ThemeConfig    `yaml:"theme"`
+	Renderer RendererConfig `yaml:"renderer"`
+	Editor   EditorConfig   `yaml:"editor"`
 }
 
 func DefaultConfig() Config {
 	return Config{
 		UI: UIConfig{
-			HideHeader:      false,
-			HideFooter:      false,
-			ShowFileTree:    true,
-			FileTreeWidth:   30,
-			SearchTreeWidth: 50,
-			Icons:           "nerd-fonts-status",
-			ColorFileNames:  true,
-			SideBySide:      true,
-			ShowDiffStats:   true,
+			HideHeader:          false,
+			HideFooter:          false,
+			ShowFileTree:        true,
+			FileTreeWidth:       30,
+			FileTreeHeight:      12,
+			SearchTreeWidth:     50,
+			Layout:              "left",
+			AutoLayoutWidth:     100,
+			Icons:               "nerd-fonts-status",
+			ColorFileNames:      true,
+			SideBySide:          true,
+			AutoSideBySideWidth: 140,
+			ShowDiffStats:       true,
+			Sort:                "tree",
+			FileTreeMode:        "tree",
+			Generated:           "collapse",
+		},
+		Theme: ThemeConfig{
+			Name: "auto",
+		},
+		Renderer: RendererConfig{
+			Delta: DeltaConfig{
+				Presets: map[string]DeltaOptions{
+					"compact":      {Args: []string{"--hunk-header-style=omit"}},
+					"line-numbers": {Args: []string{"--line-numbers"}},
+				},
+			},
 		},
 	}
 }
 
+// Path returns the path of the config file, whether it exists or not.
+func Path() string {
+	return getConfigFilePath()
+}
+
 func getConfigFilePath() string {
 	var configDirs []string
 
@@ -81,23 +193,3 @@ func getConfigFilePath() string {
 	}
 	return ""
 }
-
-func Load() Config {
-	cfg := DefaultConfig()
-
-	configPath := getConfigFilePath()
-	if configPath == "" {
-		return cfg
-	}
-
-	data, err := os.ReadFile(configPath)
-	if err != nil {
-		return cfg
-	}
-
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return DefaultConfig()
-	}
-
-	return cfg
-}
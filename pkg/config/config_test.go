@@ -0,0 +1,105 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseReportsFieldErrors(t *testing.T) {
+	data := `ui:
+  icons: emoji
+  fileTreeWidth: -4
+  sideBySid: true
+  hiddenStatuses: [added, untracked]
+theme:
+  name: solarized
+`
+	cfg, err := Parse([]byte(data))
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		`line 2: ui.icons: invalid value "emoji"`,
+		"line 3: ui.fileTreeWidth: must be a positive number",
+		"line 4: ui.sideBySid: unknown field",
+		`line 5: ui.hiddenStatuses[1]: invalid status "untracked"`,
+		`line 7: theme.name: invalid value "solarized"`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+	if cfg.UI.Icons != "emoji" {
+		t.Errorf("expected the values to be read despite errors, got icons %q", cfg.UI.Icons)
+	}
+}
+
+func TestParseReportsTypeAndSyntaxErrors(t *testing.T) {
+	if _, err := Parse([]byte("ui:\n  fileTreeWidth: wide\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a type error on line 2, got %v", err)
+	}
+	if _, err := Parse([]byte("ui: [\n")); err == nil {
+		t.Error("expected a syntax error")
+	}
+}
+
+func TestParseKeyLists(t *testing.T) {
+	cfg, err := Parse([]byte("keys:\n  nextFile: J\n  prevFile: [K, p]\n  toggleViewed: []\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Keys["nextFile"]; len(got) != 1 || got[0] != "J" {
+		t.Errorf("expected a single key to be read as a list, got %v", got)
+	}
+	if got := cfg.Keys["prevFile"]; len(got) != 2 {
+		t.Errorf("expected two keys, got %v", got)
+	}
+	if got, ok := cfg.Keys["toggleViewed"]; !ok || len(got) != 0 {
+		t.Errorf("expected an empty list, got %v", got)
+	}
+}
+
+func TestDefaultFileIsValid(t *testing.T) {
+	data, err := DefaultFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Parse(data)
+	if err != nil {
+		t.Fatalf("expected the default file to be valid, got %v", err)
+	}
+	if cfg.UI.FileTreeWidth != DefaultConfig().UI.FileTreeWidth {
+		t.Errorf("expected the default file to hold the defaults, got width %d", cfg.UI.FileTreeWidth)
+	}
+	if !strings.Contains(string(data), "# "+docs["ui.sort"]) {
+		t.Errorf("expected fields to be commented, got:\n%s", data)
+	}
+}
+
+func TestParsePresetsReplaceDefaults(t *testing.T) {
+	cfg, err := Parse([]byte("renderer:\n  delta:\n    presets:\n      wide-tabs:\n        args: [--tabs=8]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slices.Sorted(maps.Keys(cfg.Renderer.Delta.Presets)); !slices.Equal(got, []string{"wide-tabs"}) {
+		t.Errorf("expected the presets of the file only, got %v", got)
+	}
+
+	cfg, err = Parse([]byte("renderer:\n  delta:\n    presets: {}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Renderer.Delta.Presets) != 0 {
+		t.Errorf("expected no presets, got %v", cfg.Renderer.Delta.Presets)
+	}
+
+	cfg, err = Parse([]byte("renderer:\n  delta:\n    args: [--navigate]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Renderer.Delta.Presets) != len(DefaultConfig().Renderer.Delta.Presets) {
+		t.Errorf("expected the built-in presets, got %v", cfg.Renderer.Delta.Presets)
+	}
+}
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoFileName is the name of the config file looked up at the root of the
+// repository, whose settings are shared by everyone working on it.
+const RepoFileName = ".diffnav.yml"
+
+// RepoFields lists the fields read from the repository's config file, with
+// the ones under them. The others can run commands, such as the editor's, or
+// rebind keys, which the branch of an untrusted pull request mustn't do, and
+// are reported. So are the delta args running a program.
+var RepoFields = []string{"ui", "theme", "renderer.delta"}
+
+// envPrefix starts the environment variables overriding the ui fields, e.g.
+// DIFFNAV_UI_SIDEBYSIDE.
+const envPrefix = "DIFFNAV_"
+
+// Load builds the config from its layers, each overriding the previous ones:
+// the defaults, the global config file, the repository's config file found
+// in root, of which only the RepoFields are read, and the DIFFNAV_UI_*
+// environment variables. Command line flags are applied on top by the caller
+// with Override.
+func Load(root string) (Config, error) {
+	cfg := DefaultConfig()
+
+	var errs []error
+	if err := cfg.mergeFile(getConfigFilePath(), nil); err != nil {
+		errs = append(errs, err)
+	}
+	if root != "" {
+		if err := cfg.mergeFile(filepath.Join(root, RepoFileName), RepoFields); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := cfg.Override(EnvOverrides(os.Environ())); err != nil {
+		errs = append(errs, fmt.Errorf("environment:\n%w", err))
+	}
+	return cfg, errors.Join(errs...)
+}
+
+// Paths returns the config files read by Load, in order. They may not exist.
+func Paths(root string) []string {
+	paths := []string{}
+	if path := getConfigFilePath(); path != "" {
+		paths = append(paths, path)
+	}
+	if root != "" {
+		paths = append(paths, filepath.Join(root, RepoFileName))
+	}
+	return paths
+}
+
+// LoadFile reads the config file at path over the defaults.
+func LoadFile(path string) (Config, error) {
+	cfg := DefaultConfig()
+	err := cfg.mergeFile(path, nil)
+	return cfg, err
+}
+
+// mergeFile reads the given fields of the config file at path over c, or all
+// of them if fields is nil. A missing file is ignored.
+func (c *Config) mergeFile(path string, fields []string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := c.merge(data, fields); err != nil {
+		return fmt.Errorf("%s:\n%w", path, err)
+	}
+	return nil
+}
+
+// Field is a field of the ui section that can be set by an environment
+// variable and a command line flag.
+type Field struct {
+	// Path is the path of the field in the config file, e.g. "ui.sideBySide".
+	Path string
+	// Env is the environment variable overriding the field, e.g.
+	// DIFFNAV_UI_SIDEBYSIDE.
+	Env string
+	// Flag is the name of the command line flag overriding the field, e.g.
+	// "side-by-side".
+	Flag string
+	Doc  string
+	Kind reflect.Kind
+}
+
+// UIFields lists the fields of the ui section, including nested ones.
+func UIFields() []Field {
+	return structFields(reflect.TypeOf(UIConfig{}), "ui")
+}
+
+func structFields(t reflect.Type, path string) []Field {
+	var fields []Field
+	for i := range t.NumField() {
+		f := t.Field(i)
+		fieldPath := joinPath(path, yamlName(f))
+		if f.Type.Kind() == reflect.Struct {
+			fields = append(fields, structFields(f.Type, fieldPath)...)
+			continue
+		}
+		fields = append(fields, Field{
+			Path: fieldPath,
+			Env:  envPrefix + strings.ToUpper(strings.ReplaceAll(fieldPath, ".", "_")),
+			Flag: flagName(strings.TrimPrefix(fieldPath, "ui.")),
+			Doc:  docs[fieldPath],
+			Kind: f.Type.Kind(),
+		})
+	}
+	return fields
+}
+
+// flagName turns a field path into a flag name, e.g. "reviewOrder.first"
+// into "review-order-first".
+func flagName(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch {
+		case r == '.':
+			b.WriteRune('-')
+		case unicode.IsUpper(r):
+			b.WriteRune('-')
+			b.WriteRune(unicode.ToLower(r))
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// EnvOverrides returns the values of the DIFFNAV_UI_* variables in environ,
+// by field path.
+func EnvOverrides(environ []string) map[string]string {
+	vars := map[string]string{}
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = v
+		}
+	}
+
+	values := map[string]string{}
+	for _, f := range UIFields() {
+		if v, ok := vars[f.Env]; ok {
+			values[f.Path] = v
+		}
+	}
+	return values
+}
+
+// Override sets ui fields from values given as strings by field path, as
+// read from environment variables and flags. Lists are comma separated.
+func (c *Config) Override(values map[string]string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	var errs []error
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, f := range UIFields() {
+		value, ok := values[f.Path]
+		if !ok {
+			continue
+		}
+		node, err := valueNode(f.Kind, value)
+		if err != nil {
+			errs = append(errs, &Error{Field: f.Path, Msg: err.Error()})
+			continue
+		}
+		setNode(doc, strings.Split(f.Path, "."), node)
+	}
+	if err := c.apply(doc); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// valueNode parses a value given as a string into a node of the given kind.
+func valueNode(kind reflect.Kind, value string) (*yaml.Node, error) {
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}, nil
+	case reflect.Int:
+		if _, err := strconv.Atoi(value); err != nil {
+			return nil, fmt.Errorf("invalid number %q", value)
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}, nil
+	case reflect.Slice:
+		seq := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				seq.Content = append(seq.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: item})
+			}
+		}
+		return seq, nil
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}, nil
+	}
+}
+
+// setNode sets the value at path in the mapping node, creating the mappings
+// along the way.
+func setNode(mapping *yaml.Node, path []string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			mapping.Content[i+1] = value
+		} else {
+			setNode(mapping.Content[i+1], path[1:], value)
+		}
+		return
+	}
+
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}
+	if len(path) == 1 {
+		mapping.Content = append(mapping.Content, key, value)
+		return
+	}
+	child := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	setNode(child, path[1:], value)
+	mapping.Content = append(mapping.Content, key, child)
+}
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLoadLayersRepoConfigAndEnv(t *testing.T) {
+	global := t.TempDir()
+	repo := t.TempDir()
+	t.Setenv("DIFFNAV_CONFIG_DIR", global)
+	writeFile(t, filepath.Join(global, "config.yml"), `ui:
+  sort: churn
+  fileTreeWidth: 40
+  exclude: ["*.lock"]
+  reviewOrder:
+    first: ["go.mod"]
+theme:
+  colors:
+    accent: "4"
+`)
+	writeFile(t, filepath.Join(repo, RepoFileName), `ui:
+  sort: path
+  exclude: ["gen/**"]
+  reviewOrder:
+    last: ["*_test.go"]
+theme:
+  colors:
+    muted: "8"
+`)
+	t.Setenv("DIFFNAV_UI_SIDEBYSIDE", "false")
+	t.Setenv("DIFFNAV_UI_SORT", "status")
+
+	cfg, err := Load(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UI.FileTreeWidth != 40 {
+		t.Errorf("expected the global width to be kept, got %d", cfg.UI.FileTreeWidth)
+	}
+	if !slices.Equal(cfg.UI.Exclude, []string{"gen/**"}) {
+		t.Errorf("expected the repo config to replace lists, got %v", cfg.UI.Exclude)
+	}
+	if !slices.Equal(cfg.UI.ReviewOrder.First, []string{"go.mod"}) || !slices.Equal(cfg.UI.ReviewOrder.Last, []string{"*_test.go"}) {
+		t.Errorf("expected nested sections to be merged, got %+v", cfg.UI.ReviewOrder)
+	}
+	if cfg.Theme.Colors["accent"] != "4" || cfg.Theme.Colors["muted"] != "8" {
+		t.Errorf("expected maps to be merged, got %v", cfg.Theme.Colors)
+	}
+	if cfg.UI.SideBySide || cfg.UI.Sort != "status" {
+		t.Errorf("expected the environment to override the files, got sideBySide=%v sort=%q", cfg.UI.SideBySide, cfg.UI.Sort)
+	}
+
+	if err := cfg.Override(map[string]string{"ui.sort": "extension", "ui.hiddenStatuses": "added, binary"}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.UI.Sort != "extension" || !slices.Equal(cfg.UI.HiddenStatuses, []string{"added", "binary"}) {
+		t.Errorf("expected flags to override the environment, got sort=%q hidden=%v", cfg.UI.Sort, cfg.UI.HiddenStatuses)
+	}
+}
+
+func TestLoadReportsErrorsPerLayer(t *testing.T) {
+	global := t.TempDir()
+	repo := t.TempDir()
+	t.Setenv("DIFFNAV_CONFIG_DIR", global)
+	writeFile(t, filepath.Join(repo, RepoFileName), "ui:\n  icons: emoji\n")
+	t.Setenv("DIFFNAV_UI_FILETREEWIDTH", "wide")
+
+	_, err := Load(repo)
+	if err == nil {
+		t.Fatal("expected errors")
+	}
+	for _, want := range []string{
+		filepath.Join(repo, RepoFileName) + ":\nline 2: ui.icons",
+		"environment:\nui.fileTreeWidth: invalid number \"wide\"",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in:\n%v", want, err)
+		}
+	}
+}
+
+func TestUIFields(t *testing.T) {
+	var sideBySide, first Field
+	for _, f := range UIFields() {
+		switch f.Path {
+		case "ui.sideBySide":
+			sideBySide = f
+		case "ui.reviewOrder.first":
+			first = f
+		}
+	}
+	if sideBySide.Env != "DIFFNAV_UI_SIDEBYSIDE" || sideBySide.Flag != "side-by-side" {
+		t.Errorf("unexpected names for ui.sideBySide: %+v", sideBySide)
+	}
+	if first.Env != "DIFFNAV_UI_REVIEWORDER_FIRST" || first.Flag != "review-order-first" {
+		t.Errorf("unexpected names for ui.reviewOrder.first: %+v", first)
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadReportsUnsafeRepoFields(t *testing.T) {
+	global := t.TempDir()
+	repo := t.TempDir()
+	t.Setenv("DIFFNAV_CONFIG_DIR", global)
+	writeFile(t, filepath.Join(global, "config.yml"), "editor:\n  preset: helix\n")
+	writeFile(t, filepath.Join(repo, RepoFileName), `ui:
+  sort: path
+editor:
+  command: "touch /tmp/pwned"
+renderer:
+  delta:
+    args: ["--tabs=2"]
+    sideBySide:
+      args: ["--pager=sh"]
+    presets:
+      wide:
+        args: ["--paging", "always"]
+keys:
+  quit: x
+`)
+
+	cfg, err := Load(repo)
+	for _, want := range []string{
+		"line 3: editor: only read from the global config file",
+		`line 9: renderer.delta.sideBySide.args: "--pager=sh" is only read from the global config file`,
+		`line 12: renderer.delta.presets.wide.args: "--paging" is only read from the global config file`,
+		"line 13: keys: only read from the global config file",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q to be reported, got %v", want, err)
+		}
+	}
+	if cfg.UI.Sort != "path" {
+		t.Errorf("expected the repo's ui section to be read, got sort=%q", cfg.UI.Sort)
+	}
+	if !slices.Equal(cfg.Renderer.Delta.Args, []string{"--tabs=2"}) {
+		t.Errorf("expected the repo's delta args to be read, got %v", cfg.Renderer.Delta.Args)
+	}
+	if cfg.Editor.Command != "" || cfg.Editor.Preset != "helix" {
+		t.Errorf("expected the repo's editor section to be ignored, got %+v", cfg.Editor)
+	}
+	if len(cfg.Renderer.Delta.SideBySide.Args) > 0 || len(cfg.Renderer.Delta.Presets["wide"].Args) > 0 || len(cfg.Keys) > 0 {
+		t.Errorf("expected the unsafe args and the keys to be ignored, got %+v %v", cfg.Renderer.Delta, cfg.Keys)
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaFileName is the name of the JSON Schema written next to the config
+// file by `diffnav config init`.
+const SchemaFileName = "schema.json"
+
+// docs describes the fields of the config file, by field path. It's used for
+// the comments of the default config file and the descriptions of the schema.
+var docs = map[string]string{
+	"ui":                     "Appearance and behavior of the UI",
+	"ui.hideHeader":          "Hide the header to get more screen space for diffs",
+	"ui.hideFooter":          "Hide the footer (keybindings help)",
+	"ui.showFileTree":        "Show the file tree on startup",
+	"ui.fileTreeWidth":       "Width of the file tree",
+	"ui.fileTreeHeight":      "Height of the file tree and its search box when stacked above the diff",
+	"ui.searchTreeWidth":     "Width of the search panel",
+	"ui.layout":              "Position of the file tree; auto stacks it above the diff in narrow terminals",
+	"ui.autoLayoutWidth":     "Terminal width below which the auto layout stacks the file tree above the diff",
+	"ui.icons":               "Icon style of the file tree",
+	"ui.colorFileNames":      "Color file names by their git status",
+	"ui.showDiffStats":       "Show the amount of lines added / removed next to each file",
+	"ui.dirChurnBar":         "Show each directory's share of the changes as a bar",
+	"ui.skipViewed":          "Skip viewed files when moving to the next/previous file",
+	"ui.sideBySide":          "Use the side-by-side diff view (false for unified)",
+	"ui.autoSideBySide":      "Use side-by-side only when the diff is wide enough and the file has changes on both sides",
+	"ui.autoSideBySideWidth": "Width of the diff from which the auto mode uses side-by-side",
+	"ui.sort":                "Order of the files",
+	"ui.fileTreeMode":        "Show files as a nested tree or a flat list",
+	"ui.hiddenStatuses":      "File statuses hidden on startup",
+	"ui.exclude":             "Glob patterns of files to hide",
+	"ui.collapse":            "Glob patterns of files to move into the collapsed \"generated\" group",
+	"ui.generated":           "What to do with detected generated and vendored files",
+	"ui.reviewOrder":         "Glob patterns of files listed before or after the rest, whatever the sort",
+	"ui.reviewOrder.first":   "Glob patterns of files listed first",
+	"ui.reviewOrder.last":    "Glob patterns of files listed last",
+	"keys":                   "Keys of each action, e.g. nextFile: [J] - an empty list unbinds the action",
+	"theme":                  "Colors of the UI",
+	"theme.name":             "Built-in theme; auto picks dark or light based on the terminal's background",
+	"theme.colors":           "Overrides of the theme's named colors, as hex colors or ANSI color numbers",
+
+	"renderer":                           "Options of the program rendering the diffs",
+	"renderer.delta":                     "Options passed to delta, after the ones diffnav sets",
+	"renderer.delta.args":                "Args passed to delta, e.g. [--line-numbers]",
+	"renderer.delta.features":            "Space separated delta features, as defined in your gitconfig",
+	"renderer.delta.unified":             "Args and features added in the unified view",
+	"renderer.delta.unified.args":        "Args passed to delta in the unified view",
+	"renderer.delta.unified.features":    "Delta features used in the unified view",
+	"renderer.delta.sideBySide":          "Args and features added in the side-by-side view",
+	"renderer.delta.sideBySide.args":     "Args passed to delta in the side-by-side view",
+	"renderer.delta.sideBySide.features": "Delta features used in the side-by-side view",
+	"renderer.delta.presets":             "Named sets of args and features, cycled through with P",
+
+	"editor":         "Command opening files in an editor, $EDITOR if unset",
+	"editor.command": "Template of the command, with {{.Path}}, {{.Line}} and {{.Column}}",
+	"editor.preset":  "Editor with a known command, used when command isn't set",
+}
+
+// DefaultFile returns a config file holding the defaults, with a comment
+// describing each field.
+func DefaultFile() ([]byte, error) {
+	var doc yaml.Node
+	if err := doc.Encode(DefaultConfig()); err != nil {
+		return nil, err
+	}
+	commentFields(&doc, "")
+	doc.HeadComment = "yaml-language-server: $schema=" + SchemaFileName + "\n\n" +
+		"diffnav config, see https://github.com/dlvhdr/diffnav#configuration"
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func commentFields(node *yaml.Node, path string) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		field := joinPath(path, k.Value)
+		k.HeadComment = docs[field]
+		if choices, ok := Choices[field]; ok {
+			k.LineComment = "one of: " + strings.Join(choices, ", ")
+		}
+		commentFields(v, field)
+	}
+}
+
+// Schema returns the JSON Schema of the config file, for editors using
+// yaml-language-server.
+func Schema() map[string]any {
+	s := typeSchema(reflect.ValueOf(DefaultConfig()), "")
+	s["$schema"] = "http://json-schema.org/draft-07/schema#"
+	s["title"] = "diffnav config"
+	return s
+}
+
+// typeSchema describes the field at path, whose default is v.
+func typeSchema(v reflect.Value, path string) map[string]any {
+	s := map[string]any{}
+	if doc, ok := docs[path]; ok {
+		s["description"] = doc
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		props := map[string]any{}
+		for i := range v.NumField() {
+			name := yamlName(v.Type().Field(i))
+			props[name] = typeSchema(v.Field(i), joinPath(path, name))
+		}
+		s["type"] = "object"
+		s["properties"] = props
+		s["additionalProperties"] = false
+	case reflect.Map:
+		s["type"] = "object"
+		switch {
+		case v.Type() == reflect.TypeOf(KeysConfig{}):
+			s["additionalProperties"] = map[string]any{
+				"oneOf": []any{
+					map[string]any{"type": "string"},
+					map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				},
+			}
+		case v.Type().Elem().Kind() == reflect.Struct:
+			s["additionalProperties"] = typeSchema(reflect.Zero(v.Type().Elem()), "")
+		default:
+			s["additionalProperties"] = map[string]any{"type": "string"}
+		}
+	case reflect.Slice:
+		items := map[string]any{"type": "string"}
+		if choices, ok := Choices[path]; ok {
+			items["enum"] = choices
+		}
+		s["type"] = "array"
+		s["items"] = items
+	case reflect.Bool:
+		s["type"] = "boolean"
+		s["default"] = v.Bool()
+	case reflect.Int:
+		s["type"] = "integer"
+		s["minimum"] = 1
+		s["default"] = v.Int()
+	case reflect.String:
+		s["type"] = "string"
+		if choices, ok := Choices[path]; ok {
+			s["enum"] = choices
+		}
+		s["default"] = v.String()
+	}
+	return s
+}
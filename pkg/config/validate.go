@@ -0,0 +1,301 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Choices lists the values accepted by the fields that take one of a fixed
+// set of values, by field path.
+var Choices = map[string][]string{
+	"ui.icons": {
+		"nerd-fonts-status", "nerd-fonts-simple", "nerd-fonts-filetype",
+		"nerd-fonts-full", "unicode", "ascii",
+	},
+	"ui.sort":           {"tree", "path", "churn", "additions", "deletions", "status", "extension"},
+	"ui.fileTreeMode":   {"tree", "flat"},
+	"ui.layout":         {"auto", "left", "right", "top"},
+	"ui.hiddenStatuses": {"added", "modified", "deleted", "renamed", "binary"},
+	"ui.generated":      {"collapse", "hide", "show"},
+	"theme.name":        {"auto", "dark", "light", "high-contrast"},
+	"editor.preset":     {"vim", "nvim", "helix", "vscode", "emacs"},
+}
+
+// Error is a problem with a field of the config file.
+type Error struct {
+	// Line is the line of the field in the file, or 0 if unknown.
+	Line  int
+	Field string
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.Field, e.Msg)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Msg)
+}
+
+// Parse reads a config file on top of the defaults. Unknown fields and
+// invalid values are reported along with the config, in which they are left
+// as they were read.
+func Parse(data []byte) (Config, error) {
+	cfg := DefaultConfig()
+	err := cfg.merge(data, nil)
+	return cfg, err
+}
+
+// merge reads the given fields of a config file over c, or all of them if
+// fields is nil, keeping the fields the file doesn't set. The others are
+// reported, along with delta args that can run a program.
+// Lists are replaced rather than appended to, and so are the delta presets,
+// so that the built-in ones can be left out.
+func (c *Config) merge(data []byte, fields []string) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 {
+		return nil
+	}
+	doc := root.Content[0]
+	var errs []error
+	if fields != nil && doc.Kind == yaml.MappingNode {
+		// They're dropped before they're decoded.
+		errs = append(errs, keepFields(doc, fields, "")...)
+		errs = append(errs, dropUnsafeDeltaArgs(doc)...)
+	}
+	// The decoder adds the entries of a mapping to the map it decodes into.
+	if findNode(doc, "renderer", "delta", "presets") != nil {
+		c.Renderer.Delta.Presets = nil
+	}
+	return errors.Join(append(errs, c.apply(doc))...)
+}
+
+// keepFields drops the entries of the mapping node at path that aren't one
+// of fields, or a parent of one, and reports them.
+func keepFields(node *yaml.Node, fields []string, path string) []error {
+	var errs []error
+	var content []*yaml.Node
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		k, v := node.Content[i], node.Content[i+1]
+		fieldPath := joinPath(path, k.Value)
+		switch {
+		case slices.ContainsFunc(fields, func(f string) bool {
+			return fieldPath == f || strings.HasPrefix(fieldPath, f+".")
+		}):
+			content = append(content, k, v)
+		case v.Kind == yaml.MappingNode && slices.ContainsFunc(fields, func(f string) bool {
+			return strings.HasPrefix(f, fieldPath+".")
+		}):
+			errs = append(errs, keepFields(v, fields, fieldPath)...)
+			content = append(content, k, v)
+		default:
+			errs = append(errs, &Error{Line: k.Line, Field: fieldPath, Msg: "only read from the global config file"})
+		}
+	}
+	node.Content = content
+	return errs
+}
+
+// unsafeDeltaArgs are the delta options that make it run a program or read
+// another config.
+var unsafeDeltaArgs = []string{"--pager", "--paging", "--config"}
+
+// dropUnsafeDeltaArgs drops the lists of delta args holding one of the
+// unsafeDeltaArgs, and reports them.
+func dropUnsafeDeltaArgs(doc *yaml.Node) []error {
+	delta := findNode(doc, "renderer", "delta")
+	if delta == nil {
+		return nil
+	}
+	paths := [][]string{{"args"}, {"unified", "args"}, {"sideBySide", "args"}}
+	if presets := findNode(delta, "presets"); presets != nil && presets.Kind == yaml.MappingNode {
+		for i := 0; i < len(presets.Content); i += 2 {
+			paths = append(paths, []string{"presets", presets.Content[i].Value, "args"})
+		}
+	}
+
+	var errs []error
+	for _, path := range paths {
+		args := findNode(delta, path...)
+		if args == nil || args.Kind != yaml.SequenceNode {
+			continue
+		}
+		var unsafe []error
+		for _, arg := range args.Content {
+			if slices.ContainsFunc(unsafeDeltaArgs, func(opt string) bool {
+				return arg.Value == opt || strings.HasPrefix(arg.Value, opt+"=")
+			}) {
+				unsafe = append(unsafe, &Error{
+					Line:  arg.Line,
+					Field: "renderer.delta." + strings.Join(path, "."),
+					Msg:   fmt.Sprintf("%q is only read from the global config file", arg.Value),
+				})
+			}
+		}
+		if len(unsafe) > 0 {
+			args.Content = nil
+			errs = append(errs, unsafe...)
+		}
+	}
+	return errs
+}
+
+// findNode returns the value at path in the mapping node, or nil if it's not
+// set.
+func findNode(node *yaml.Node, path ...string) *yaml.Node {
+	for _, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var value *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == name {
+				value = node.Content[i+1]
+			}
+		}
+		if value == nil {
+			return nil
+		}
+		node = value
+	}
+	return node
+}
+
+// apply decodes doc over c and checks the fields it sets.
+func (c *Config) apply(doc *yaml.Node) error {
+	var errs []error
+	if err := doc.Decode(c); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return err
+		}
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, errors.New(msg))
+		}
+	}
+
+	lines := map[string]int{}
+	errs = append(errs, checkFields(doc, reflect.TypeOf(*c), "", lines)...)
+	errs = append(errs, c.validate(lines)...)
+	return errors.Join(errs...)
+}
+
+// checkFields reports the keys of node that aren't fields of t, and records
+// the line of every field below path.
+func checkFields(node *yaml.Node, t reflect.Type, path string, lines map[string]int) []error {
+	var errs []error
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			field := joinPath(path, k.Value)
+			lines[field] = k.Line
+			f, ok := fieldByTag(t, k.Value)
+			if !ok {
+				errs = append(errs, &Error{Line: k.Line, Field: field, Msg: "unknown field"})
+				continue
+			}
+			errs = append(errs, checkFields(v, f.Type, field, lines)...)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			k, v := node.Content[i], node.Content[i+1]
+			field := joinPath(path, k.Value)
+			lines[field] = k.Line
+			errs = append(errs, checkFields(v, t.Elem(), field, lines)...)
+		}
+	case node.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for i, item := range node.Content {
+			field := path + "[" + strconv.Itoa(i) + "]"
+			lines[field] = item.Line
+			errs = append(errs, checkFields(item, t.Elem(), field, lines)...)
+		}
+	}
+	return errs
+}
+
+func fieldByTag(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if yamlName(f) == name {
+			return f, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	return name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// validate checks the values of the fields set by a config file. lines maps
+// the paths of these fields to their line in the file.
+func (c Config) validate(lines map[string]int) []error {
+	var errs []error
+	invalid := func(field, msg string) {
+		if line, ok := lines[field]; ok {
+			errs = append(errs, &Error{Line: line, Field: field, Msg: msg})
+		}
+	}
+	choice := func(field, value string) {
+		if !slices.Contains(Choices[field], value) {
+			invalid(field, fmt.Sprintf("invalid value %q, expected one of %s",
+				value, strings.Join(Choices[field], ", ")))
+		}
+	}
+
+	if c.UI.FileTreeWidth <= 0 {
+		invalid("ui.fileTreeWidth", "must be a positive number")
+	}
+	if c.UI.FileTreeHeight <= 0 {
+		invalid("ui.fileTreeHeight", "must be a positive number")
+	}
+	if c.UI.SearchTreeWidth <= 0 {
+		invalid("ui.searchTreeWidth", "must be a positive number")
+	}
+	if c.UI.AutoLayoutWidth <= 0 {
+		invalid("ui.autoLayoutWidth", "must be a positive number")
+	}
+	if c.UI.AutoSideBySideWidth <= 0 {
+		invalid("ui.autoSideBySideWidth", "must be a positive number")
+	}
+	choice("ui.icons", c.UI.Icons)
+	choice("ui.sort", c.UI.Sort)
+	choice("ui.fileTreeMode", c.UI.FileTreeMode)
+	choice("ui.layout", c.UI.Layout)
+	choice("ui.generated", c.UI.Generated)
+	for i, status := range c.UI.HiddenStatuses {
+		if !slices.Contains(Choices["ui.hiddenStatuses"], status) {
+			invalid(fmt.Sprintf("ui.hiddenStatuses[%d]", i), fmt.Sprintf(
+				"invalid status %q, expected one of %s",
+				status, strings.Join(Choices["ui.hiddenStatuses"], ", ")))
+		}
+	}
+	if c.Theme.Name != "" {
+		choice("theme.name", c.Theme.Name)
+	}
+	if c.Editor.Preset != "" {
+		choice("editor.preset", c.Editor.Preset)
+	}
+	if _, err := template.New("editor").Parse(c.Editor.Command); err != nil {
+		invalid("editor.command", fmt.Sprintf("invalid template: %v", err))
+	}
+	return errs
+}
@@ -1,11 +1,100 @@
 package dirnode
 
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// churnBarWidth is the number of cells used by the churn bar.
+const churnBarWidth = 5
+
 type DirNode struct {
 	FullPath string
 	Name     string
+	// Generated marks the group holding files collapsed as generated or vendored.
+	Generated bool
+	// Group is the owner group the directory is listed under when the tree is
+	// grouped by owner. The same directory may appear in several groups.
+	Group string
+
+	// Aggregated stats of all the files below this directory.
+	Additions int64
+	Deletions int64
+	Files     int
+	// TotalChurn is the churn of the whole diff, used to scale the churn bar.
+	TotalChurn int64
+	Cfg        config.Config
 }
 
 // DirNode implements fmt.Stringer which charm.land/bubbles uses to render it in the tree bubble.
 func (d *DirNode) String() string {
-	return d.Name
+	stats := d.StatsView()
+	if stats == "" {
+		return d.Name
+	}
+	return utils.RemoveReset(d.Name + " " + stats)
+}
+
+// DiffPath identifies the directory's diff, which only holds the files of its
+// group when the tree is grouped by owner.
+func (d *DirNode) DiffPath() string {
+	if d.Group == "" {
+		return d.FullPath
+	}
+	return d.Group + ": " + d.FullPath
+}
+
+// StatsView renders the aggregated stats shown after the directory name, or
+// an empty string when stats are disabled.
+func (d *DirNode) StatsView() string {
+	if !d.Cfg.UI.ShowDiffStats || d.Files == 0 {
+		return ""
+	}
+
+	base := lipgloss.NewStyle()
+	dim := base.Foreground(theme.Current().Muted)
+	parts := []string{}
+	if stats := filenode.ViewDiffStats(d.Additions, d.Deletions, base); stats != "" {
+		parts = append(parts, stats)
+	}
+	parts = append(parts, dim.Render(fmt.Sprintf("(%d)", d.Files)))
+	// The root always holds all of the churn, so a bar would add nothing.
+	if d.Cfg.UI.DirChurnBar && d.FullPath != "/" {
+		parts = append(parts, d.churnBar())
+	}
+	return strings.Join(parts, " ")
+}
+
+// StatsWidth returns the width of StatsView including the separating space.
+func (d *DirNode) StatsWidth() int {
+	stats := d.StatsView()
+	if stats == "" {
+		return 0
+	}
+	return lipgloss.Width(stats) + 1
+}
+
+// churnBar renders the share of the total churn that happened in this directory.
+func (d *DirNode) churnBar() string {
+	filled := 0
+	if d.TotalChurn > 0 {
+		churn := d.Additions + d.Deletions
+		filled = int((churn*churnBarWidth + d.TotalChurn - 1) / d.TotalChurn)
+		filled = min(filled, churnBarWidth)
+	}
+
+	full, empty := "▰", "▱"
+	if d.Cfg.UI.Icons == filenode.IconsASCII {
+		full, empty = "#", "-"
+	}
+	t := theme.Current()
+	return lipgloss.NewStyle().Foreground(t.Churn).Render(strings.Repeat(full, filled)) +
+		lipgloss.NewStyle().Foreground(t.Muted).Render(strings.Repeat(empty, churnBarWidth-filled))
 }
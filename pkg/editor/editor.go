@@ -0,0 +1,176 @@
+// Package editor builds the command opening a file in the user's editor, at
+// a given line and column.
+package editor
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Preset is the command of an editor known to diffnav.
+type Preset struct {
+	// Program is run when the preset is picked in the config.
+	Program string
+	// Args is the template of the args opening a file at a position.
+	Args string
+	// Programs are the names the editor is known by in $EDITOR.
+	Programs []string
+}
+
+// Presets holds the editors known to diffnav, by name.
+var Presets = map[string]Preset{
+	"vim": {
+		Program:  "vim",
+		Args:     "+{{.Line}} {{.Path}}",
+		Programs: []string{"vim", "vi", "gvim"},
+	},
+	"nvim": {
+		Program:  "nvim",
+		Args:     "+{{.Line}} {{.Path}}",
+		Programs: []string{"nvim"},
+	},
+	"helix": {
+		Program:  "hx",
+		Args:     "{{.Path}}:{{.Line}}:{{.Column}}",
+		Programs: []string{"hx", "helix"},
+	},
+	"vscode": {
+		Program:  "code",
+		Args:     "--goto {{.Path}}:{{.Line}}:{{.Column}}",
+		Programs: []string{"code", "code-insiders", "codium"},
+	},
+	"emacs": {
+		Program:  "emacs",
+		Args:     "+{{.Line}}:{{.Column}} {{.Path}}",
+		Programs: []string{"emacs", "emacsclient"},
+	},
+}
+
+// Names returns the names of the presets, sorted.
+func Names() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Location is the file to open and the position of the cursor in it,
+// counting from 1.
+type Location struct {
+	Path   string
+	Line   int
+	Column int
+}
+
+// Template returns the template of the command opening a file: command if
+// set, else the one of the preset, else $EDITOR (given as env) followed by
+// the args of its preset if it's a known editor, or by the path.
+func Template(command, preset, env string) (string, error) {
+	if command != "" {
+		return command, nil
+	}
+	if preset != "" {
+		p, ok := Presets[preset]
+		if !ok {
+			return "", fmt.Errorf("unknown editor preset %q", preset)
+		}
+		return p.Program + " " + p.Args, nil
+	}
+
+	fields := strings.Fields(env)
+	if len(fields) == 0 {
+		return "", errors.New("no editor set, set $EDITOR or editor.preset")
+	}
+	program := filepath.Base(fields[0])
+	for _, p := range Presets {
+		if slices.Contains(p.Programs, program) {
+			return env + " " + p.Args, nil
+		}
+	}
+	return env + " {{.Path}}", nil
+}
+
+// Command returns the args of the command opening loc with the template,
+// followed by the paths of more files to open.
+func Command(tmpl string, loc Location, more ...string) ([]string, error) {
+	fields, err := split(tmpl)
+	if err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("empty editor command")
+	}
+
+	args := make([]string, 0, len(fields)+len(more))
+	for _, field := range fields {
+		t, err := template.New("editor").Option("missingkey=error").Parse(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid editor command: %w", err)
+		}
+		var sb strings.Builder
+		if err := t.Execute(&sb, loc); err != nil {
+			return nil, fmt.Errorf("invalid editor command: %w", err)
+		}
+		args = append(args, sb.String())
+	}
+	return append(args, more...), nil
+}
+
+// split splits a command into its args on spaces, except within quotes or
+// template actions, so that paths with spaces stay whole.
+func split(command string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote rune
+	actions := 0
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+		case actions > 0 && r == '}' && i+1 < len(runes) && runes[i+1] == '}':
+			actions--
+			arg.WriteString("}}")
+			i++
+			continue
+		case r == '{' && i+1 < len(runes) && runes[i+1] == '{':
+			actions++
+			arg.WriteString("{{")
+			inArg = true
+			i++
+			continue
+		case actions > 0:
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+			continue
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+			continue
+		}
+		arg.WriteRune(r)
+		inArg = true
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in editor command %q", command)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
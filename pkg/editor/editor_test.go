@@ -0,0 +1,83 @@
+package editor
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		preset  string
+		env     string
+		want    string
+		wantErr bool
+	}{
+		{name: "command wins", command: "ed {{.Path}}", preset: "vim", env: "nano", want: "ed {{.Path}}"},
+		{name: "preset", preset: "helix", env: "nano", want: "hx {{.Path}}:{{.Line}}:{{.Column}}"},
+		{name: "unknown preset", preset: "notepad", wantErr: true},
+		{name: "known $EDITOR", env: "/usr/bin/nvim", want: "/usr/bin/nvim +{{.Line}} {{.Path}}"},
+		{name: "$EDITOR with args", env: "code --wait", want: "code --wait --goto {{.Path}}:{{.Line}}:{{.Column}}"},
+		{name: "unknown $EDITOR", env: "nano", want: "nano {{.Path}}"},
+		{name: "nothing set", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Template(tt.command, tt.preset, tt.env)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Template() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Template() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand(t *testing.T) {
+	loc := Location{Path: "/repo/my dir/main.go", Line: 12, Column: 3}
+	tests := []struct {
+		name    string
+		tmpl    string
+		more    []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name: "vim",
+			tmpl: "vim +{{.Line}} {{.Path}}",
+			want: []string{"vim", "+12", "/repo/my dir/main.go"},
+		},
+		{
+			name: "helix",
+			tmpl: "hx {{.Path}}:{{.Line}}:{{.Column}}",
+			want: []string{"hx", "/repo/my dir/main.go:12:3"},
+		},
+		{
+			name: "quoted args",
+			tmpl: `emacsclient -a "" '+{{.Line}}:{{.Column}}' {{ .Path }}`,
+			want: []string{"emacsclient", "-a", "", "+12:3", "/repo/my dir/main.go"},
+		},
+		{
+			name: "more files",
+			tmpl: "vim {{.Path}}",
+			more: []string{"/repo/b.go"},
+			want: []string{"vim", "/repo/my dir/main.go", "/repo/b.go"},
+		},
+		{name: "unknown field", tmpl: "vim {{.Row}}", wantErr: true},
+		{name: "unterminated quote", tmpl: `vim "{{.Path}}`, wantErr: true},
+		{name: "empty", tmpl: "  ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Command(tt.tmpl, loc, tt.more...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Command() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
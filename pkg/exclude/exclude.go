@@ -0,0 +1,288 @@
+// Package exclude decides which files of a diff should be hidden or collapsed
+// into the "generated" group of the file tree.
+package exclude
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// IgnoreFileName is the repo-level file listing patterns of files to hide.
+const IgnoreFileName = ".diffnavignore"
+
+// Values for config.UIConfig.Generated.
+const (
+	GeneratedCollapse = "collapse"
+	GeneratedHide     = "hide"
+	GeneratedShow     = "show"
+)
+
+// Action is what should happen to a file in the file tree.
+type Action int
+
+const (
+	Show Action = iota
+	Hide
+	Collapse
+)
+
+// headerLines is how many lines from the top of a file are searched for a
+// "Code generated ... DO NOT EDIT" marker.
+const headerLines = 20
+
+var generatedHeader = regexp.MustCompile(`Code generated .* DO NOT EDIT`)
+
+type rule struct {
+	pattern string
+	negate  bool
+}
+
+type attrRule struct {
+	pattern   string
+	generated *bool
+	vendored  *bool
+	noDiff    *bool
+}
+
+// Matcher classifies files using the config patterns, the repo's
+// .diffnavignore and .gitattributes, and generated-code headers.
+type Matcher struct {
+	root      string
+	exclude   []string
+	collapse  []string
+	generated string
+	ignore    []rule
+	attrs     []attrRule
+}
+
+// Load builds a Matcher for the repository at root. Missing files are ignored.
+func Load(cfg config.Config, root string) Matcher {
+	m := Matcher{
+		root:      root,
+		exclude:   cfg.UI.Exclude,
+		collapse:  cfg.UI.Collapse,
+		generated: cfg.UI.Generated,
+	}
+	if root == "" {
+		return m
+	}
+
+	if f, err := os.Open(filepath.Join(root, IgnoreFileName)); err == nil {
+		m.ignore = parseIgnore(f)
+		f.Close()
+	}
+	if f, err := os.Open(filepath.Join(root, ".gitattributes")); err == nil {
+		m.attrs = parseAttributes(f)
+		f.Close()
+	}
+	return m
+}
+
+// Classify returns the action for file. Explicit exclusions win over
+// collapsing, and config patterns win over auto-detection.
+func (m Matcher) Classify(file *gitdiff.File) Action {
+	name := filenode.GetFileName(file)
+	if matchAny(m.exclude, name) || m.isIgnored(name) {
+		return Hide
+	}
+	if matchAny(m.collapse, name) {
+		return Collapse
+	}
+	if m.generated == GeneratedShow {
+		return Show
+	}
+	if m.isGenerated(file) {
+		if m.generated == GeneratedHide {
+			return Hide
+		}
+		return Collapse
+	}
+	return Show
+}
+
+// Rules describes the rules hiding files, such as "ui.exclude: *.lock", to
+// tell why files are missing.
+func (m Matcher) Rules() []string {
+	var rules []string
+	for _, p := range m.exclude {
+		rules = append(rules, "ui.exclude: "+p)
+	}
+	for _, r := range m.ignore {
+		if !r.negate {
+			rules = append(rules, IgnoreFileName+": "+r.pattern)
+		}
+	}
+	if m.generated == GeneratedHide {
+		rules = append(rules, "ui.generated: "+GeneratedHide)
+	}
+	return rules
+}
+
+func (m Matcher) isIgnored(name string) bool {
+	ignored := false
+	for _, r := range m.ignore {
+		if utils.MatchGlob(r.pattern, name) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+func (m Matcher) isGenerated(file *gitdiff.File) bool {
+	name := filenode.GetFileName(file)
+
+	// The last matching line wins for each attribute, like in git.
+	var generated, vendored, noDiff bool
+	for _, a := range m.attrs {
+		if !utils.MatchGlob(a.pattern, name) {
+			continue
+		}
+		if a.generated != nil {
+			generated = *a.generated
+		}
+		if a.vendored != nil {
+			vendored = *a.vendored
+		}
+		if a.noDiff != nil {
+			noDiff = *a.noDiff
+		}
+	}
+	if generated || vendored || noDiff {
+		return true
+	}
+
+	return hasGeneratedHeader(file) || m.fileHasGeneratedHeader(file)
+}
+
+// hasGeneratedHeader looks for the marker in the new side of the patch.
+func hasGeneratedHeader(file *gitdiff.File) bool {
+	for _, frag := range file.TextFragments {
+		line := frag.NewPosition
+		if line > headerLines {
+			break
+		}
+		for _, l := range frag.Lines {
+			if !l.New() {
+				continue
+			}
+			if line > headerLines {
+				break
+			}
+			if generatedHeader.MatchString(l.Line) {
+				return true
+			}
+			line++
+		}
+	}
+	return false
+}
+
+// fileHasGeneratedHeader looks for the marker in the file on disk, which
+// catches generated files whose patch doesn't touch the header. The file is
+// only read when it is the new side of the patch, as the diff may come from
+// another repository or revision.
+func (m Matcher) fileHasGeneratedHeader(file *gitdiff.File) bool {
+	if m.root == "" || file.IsDelete || file.NewOIDPrefix == "" {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(m.root, filenode.GetFileName(file)))
+	if err != nil || !isBlob(data, file.NewOIDPrefix) {
+		return false
+	}
+
+	scanner := bufio.NewScanner(io.LimitReader(bytes.NewReader(data), 4096))
+	for i := 0; i < headerLines && scanner.Scan(); i++ {
+		if generatedHeader.MatchString(scanner.Text()) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlob returns whether the git object id of data as a blob starts with oid,
+// which may be abbreviated. Both SHA-1 and SHA-256 repositories are handled.
+func isBlob(data []byte, oid string) bool {
+	for _, h := range []hash.Hash{sha1.New(), sha256.New()} {
+		fmt.Fprintf(h, "blob %d\x00", len(data))
+		h.Write(data)
+		if strings.HasPrefix(hex.EncodeToString(h.Sum(nil)), oid) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseIgnore(r io.Reader) []rule {
+	var rules []rule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		negate := strings.HasPrefix(line, "!")
+		rules = append(rules, rule{pattern: strings.TrimPrefix(line, "!"), negate: negate})
+	}
+	return rules
+}
+
+func parseAttributes(r io.Reader) []attrRule {
+	var rules []attrRule
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		a := attrRule{pattern: fields[0]}
+		for _, attr := range fields[1:] {
+			switch attr {
+			case "linguist-generated", "linguist-generated=true":
+				a.generated = boolPtr(true)
+			case "-linguist-generated", "linguist-generated=false":
+				a.generated = boolPtr(false)
+			case "linguist-vendored", "linguist-vendored=true":
+				a.vendored = boolPtr(true)
+			case "-linguist-vendored", "linguist-vendored=false":
+				a.vendored = boolPtr(false)
+			case "-diff", "binary":
+				a.noDiff = boolPtr(true)
+			case "diff":
+				a.noDiff = boolPtr(false)
+			}
+		}
+		if a.generated != nil || a.vendored != nil || a.noDiff != nil {
+			rules = append(rules, a)
+		}
+	}
+	return rules
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, p := range patterns {
+		if utils.MatchGlob(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
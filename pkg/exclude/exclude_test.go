@@ -0,0 +1,121 @@
+package exclude
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+)
+
+func newFile(name string, lines ...string) *gitdiff.File {
+	frag := &gitdiff.TextFragment{NewPosition: 1}
+	for _, l := range lines {
+		frag.Lines = append(frag.Lines, gitdiff.Line{Op: gitdiff.OpAdd, Line: l + "\n"})
+	}
+	return &gitdiff.File{
+		OldName:       name,
+		NewName:       name,
+		TextFragments: []*gitdiff.TextFragment{frag},
+	}
+}
+
+func TestClassify(t *testing.T) {
+	root := t.TempDir()
+	ignore := "# lockfiles\n*.lock\n!keep.lock\n"
+	if err := os.WriteFile(filepath.Join(root, IgnoreFileName), []byte(ignore), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	attrs := "*.pb.go linguist-generated\nassets/** -diff\nassets/readme.txt diff\n" +
+		"third_party/** linguist-vendored\nthird_party/patches/** -linguist-vendored\n"
+	if err := os.WriteFile(filepath.Join(root, ".gitattributes"), []byte(attrs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.UI.Exclude = []string{"vendor/**"}
+	cfg.UI.Collapse = []string{"*.snap"}
+	m := Load(cfg, root)
+
+	tests := []struct {
+		file     *gitdiff.File
+		expected Action
+	}{
+		{newFile("main.go"), Show},
+		{newFile("yarn.lock"), Hide},
+		{newFile("keep.lock"), Show},
+		{newFile("vendor/github.com/foo/foo.go"), Hide},
+		{newFile("ui/__snapshots__/view.snap"), Collapse},
+		{newFile("api/api.pb.go"), Collapse},
+		{newFile("assets/logo.svg"), Collapse},
+		{newFile("assets/readme.txt"), Show},
+		{newFile("third_party/lib/lib.go"), Collapse},
+		{newFile("third_party/patches/fix.go"), Show},
+		{newFile("mocks/mock.go", "// Code generated by MockGen. DO NOT EDIT.", "package mocks"), Collapse},
+	}
+	for _, tt := range tests {
+		if got := m.Classify(tt.file); got != tt.expected {
+			t.Errorf("%s: expected action %d, got %d", tt.file.NewName, tt.expected, got)
+		}
+	}
+}
+
+func TestClassifyReadsOnlyLocalFiles(t *testing.T) {
+	root := t.TempDir()
+	content := "// Code generated by protoc. DO NOT EDIT.\n\npackage api\n\nvar x = 1\n"
+	if err := os.WriteFile(filepath.Join(root, "api.go"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha1.Sum(fmt.Appendf(nil, "blob %d\x00%s", len(content), content))
+	m := Load(config.DefaultConfig(), root)
+
+	// The patch only touches the end of the file, below the header.
+	local := newFile("api.go", "var x = 1")
+	local.NewOIDPrefix = hex.EncodeToString(sum[:])[:7]
+	if got := m.Classify(local); got != Collapse {
+		t.Errorf("expected the header of the file on disk to be read, got action %d", got)
+	}
+
+	other := newFile("api.go", "var x = 1")
+	other.NewOIDPrefix = "1234567"
+	if got := m.Classify(other); got != Show {
+		t.Errorf("expected the file on disk to be ignored for a diff of another revision, got action %d", got)
+	}
+}
+
+func TestClassifyGeneratedModes(t *testing.T) {
+	file := newFile("gen.go", "// Code generated by stringer. DO NOT EDIT.")
+	cfg := config.DefaultConfig()
+
+	cfg.UI.Generated = GeneratedHide
+	if got := Load(cfg, "").Classify(file); got != Hide {
+		t.Fatalf("expected generated files to be hidden, got %d", got)
+	}
+
+	cfg.UI.Generated = GeneratedShow
+	if got := Load(cfg, "").Classify(file); got != Show {
+		t.Fatalf("expected generated files to be shown, got %d", got)
+	}
+}
+
+func TestRules(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, IgnoreFileName), []byte("gen/**\n!gen/keep.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.UI.Exclude = []string{"*.lock"}
+	cfg.UI.Generated = GeneratedHide
+
+	got := Load(cfg, root).Rules()
+	want := []string{"ui.exclude: *.lock", IgnoreFileName + ": gen/**", "ui.generated: hide"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected rules %v, got %v", want, got)
+	}
+}
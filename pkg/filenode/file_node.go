@@ -11,6 +11,7 @@ import (
 
 	"github.com/dlvhdr/diffnav/pkg/config"
 	"github.com/dlvhdr/diffnav/pkg/icons"
+	"github.com/dlvhdr/diffnav/pkg/theme"
 	"github.com/dlvhdr/diffnav/pkg/utils"
 )
 
@@ -31,6 +32,13 @@ type FileNode struct {
 	Selected   bool
 	PanelWidth int
 	Cfg        config.Config
+	// ShowFullPath renders the full path instead of the base name, as used by
+	// the flat list view.
+	ShowFullPath bool
+	// Viewed renders the file with a dimmed checkmark instead of its status icon.
+	Viewed bool
+	// Marked renders the file with a selection mark instead of its status icon.
+	Marked bool
 }
 
 func (f *FileNode) Path() string {
@@ -38,7 +46,7 @@ func (f *FileNode) Path() string {
 }
 
 func (f *FileNode) Value() string {
-	name := filepath.Base(f.Path())
+	name := f.displayName()
 
 	// full has a special layout: [status icon] [filename] [file-type icon]
 	if f.Cfg.UI.Icons == IconsNerdFull {
@@ -61,8 +69,8 @@ func (f *FileNode) renderStandardLayout(name string) string {
 	}
 
 	nameMaxWidth := f.PanelWidth - f.Depth - iconWidth - lipgloss.Width(stats)
-	truncatedName := utils.TruncateString(name, nameMaxWidth)
-	coloredIcon := lipgloss.NewStyle().Foreground(f.StatusColor()).Render(icon)
+	truncatedName := f.truncateName(name, nameMaxWidth)
+	coloredIcon := lipgloss.NewStyle().Foreground(f.iconColor()).Render(icon)
 
 	if f.Selected {
 		bgStyle := lipgloss.NewStyle().
@@ -89,7 +97,7 @@ func (f *FileNode) renderStandardLayout(name string) string {
 // All icons colored by git status.
 func (f *FileNode) renderFullLayout(name string) string {
 	statusIcon := f.getStatusIcon()
-	fileIcon := icons.GetIcon(name, false)
+	fileIcon := icons.GetIcon(filepath.Base(f.Path()), false)
 	style := lipgloss.NewStyle().Foreground(f.StatusColor())
 
 	stats := ""
@@ -97,11 +105,12 @@ func (f *FileNode) renderFullLayout(name string) string {
 		stats = " " + ViewFileDiffStats(f.File, lipgloss.NewStyle())
 	}
 
-	iconsPrefix := style.Render(statusIcon) + " " + style.Render(fileIcon) + " "
+	iconsPrefix := lipgloss.NewStyle().Foreground(f.iconColor()).Render(statusIcon) + " " +
+		style.Render(fileIcon) + " "
 	iconsWidth := lipgloss.Width(statusIcon) + 1 + lipgloss.Width(fileIcon) + 1
 
 	nameMaxWidth := f.PanelWidth - f.Depth - iconsWidth - lipgloss.Width(stats)
-	truncatedName := utils.TruncateString(name, nameMaxWidth)
+	truncatedName := f.truncateName(name, nameMaxWidth)
 
 	if f.Selected {
 		bgStyle := style.Bold(true)
@@ -117,62 +126,109 @@ func (f *FileNode) renderFullLayout(name string) string {
 		return iconsPrefix + style.Render(truncatedName) + stats
 	}
 	return iconsPrefix + lipgloss.NewStyle().
-		Foreground(lipgloss.Color("15")).
+		Foreground(theme.Current().Text).
 		Render(truncatedName) +
 		stats
 }
 
+// displayName returns the name shown in the tree. Renamed and copied files
+// show their old name too, with the full old path when the directory changed.
+func (f *FileNode) displayName() string {
+	name := filepath.Base(f.Path())
+	if f.ShowFullPath {
+		name = f.Path()
+	}
+
+	if (!f.File.IsRename && !f.File.IsCopy) || f.File.OldName == "" {
+		return name
+	}
+	oldName := f.File.OldName
+	if !f.ShowFullPath && filepath.Dir(oldName) == filepath.Dir(f.Path()) {
+		oldName = filepath.Base(oldName)
+	}
+	return oldName + " → " + name
+}
+
+// truncateName shortens full paths in the middle so that the file name stays
+// visible, and base names at the end.
+func (f *FileNode) truncateName(name string, maxWidth int) string {
+	if f.ShowFullPath {
+		return utils.TruncateMiddle(name, maxWidth)
+	}
+	return utils.TruncateString(name, maxWidth)
+}
+
 // getIcon returns the left icon based on the icon style.
 func (f *FileNode) getIcon() string {
+	if f.Marked {
+		return f.getMarkedIcon()
+	}
+	if f.Viewed {
+		return f.getViewedIcon()
+	}
 	name := filepath.Base(f.Path())
 	switch f.Cfg.UI.Icons {
-	case IconsNerdStatus:
-		if f.File.IsNew {
-			return ""
-		} else if f.File.IsDelete {
-			return ""
-		}
-		return ""
 	case IconsNerdSimple:
 		return ""
 	case IconsNerdFiletype:
 		return icons.GetIcon(name, false) // File-type specific icon (colored by status)
-	case IconsUnicode:
-		if f.File.IsNew {
-			return "+"
-		} else if f.File.IsDelete {
-			return "⛌"
-		}
-		return "●"
-	default: // ascii (fallback for unknown values)
-		if f.File.IsNew {
-			return "+"
-		} else if f.File.IsDelete {
-			return "x"
-		}
-		return "*"
+	default: // status, unicode and ascii (the fallback for unknown values)
+		return GetChangeKind(f.File).Icon(f.Cfg.UI.Icons)
 	}
 }
 
 // getStatusIcon returns the git status indicator icon (used by full layout).
 // Uses the same boxed icons as status style.
 func (f *FileNode) getStatusIcon() string {
-	if f.File.IsNew {
-		return "\uf457" //
-	} else if f.File.IsDelete {
-		return "\ueadf" //
+	if f.Marked {
+		return f.getMarkedIcon()
+	}
+	if f.Viewed {
+		return f.getViewedIcon()
+	}
+	return GetChangeKind(f.File).Icon(IconsNerdStatus)
+}
+
+// getViewedIcon returns the checkmark shown for viewed files.
+func (f *FileNode) getViewedIcon() string {
+	switch f.Cfg.UI.Icons {
+	case IconsUnicode:
+		return "✓"
+	case IconsASCII:
+		return "v"
+	default:
+		return "\uf00c" //
+	}
+}
+
+// getMarkedIcon returns the mark shown for selected files.
+func (f *FileNode) getMarkedIcon() string {
+	switch f.Cfg.UI.Icons {
+	case IconsUnicode:
+		return "■"
+	case IconsASCII:
+		return "@"
+	default:
+		return "\uf14a" //
+	}
+}
+
+// iconColor returns the color of the file's icon, which highlights selected
+// files.
+func (f *FileNode) iconColor() color.Color {
+	if f.Marked {
+		return theme.Current().Marked
 	}
-	return "\uf459" //
+	return f.StatusColor()
 }
 
 // StatusColor returns the color for this file based on its git status.
+// Viewed files are dimmed.
 func (f *FileNode) StatusColor() color.Color {
-	if f.File.IsNew {
-		return lipgloss.Green
-	} else if f.File.IsDelete {
-		return lipgloss.Red
+	if f.Viewed {
+		return theme.Current().Muted
 	}
-	return lipgloss.Yellow
+	return GetChangeKind(f.File).Color()
 }
 
 func (f *FileNode) String() string {
@@ -210,7 +266,7 @@ func ViewDiffStats(added, deleted int64, base lipgloss.Style) string {
 	deletedView := ""
 
 	if added > 0 {
-		addedView = base.Foreground(lipgloss.Green).Render(fmt.Sprintf("+%d", added))
+		addedView = base.Foreground(theme.Current().Added).Render(fmt.Sprintf("+%d", added))
 	}
 
 	if added > 0 && deleted > 0 {
@@ -218,7 +274,7 @@ func ViewDiffStats(added, deleted int64, base lipgloss.Style) string {
 	}
 
 	if deleted > 0 {
-		deletedView = base.Foreground(lipgloss.Red).Render(fmt.Sprintf("-%d", deleted))
+		deletedView = base.Foreground(theme.Current().Deleted).Render(fmt.Sprintf("-%d", deleted))
 	}
 
 	return lipgloss.JoinHorizontal(lipgloss.Top, addedView, deletedView)
@@ -0,0 +1,171 @@
+package filenode
+
+import (
+	"fmt"
+	"image/color"
+	"os"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/theme"
+)
+
+// Status is the kind of change a file went through, as used by the status
+// filters.
+type Status int
+
+const (
+	StatusModified Status = iota
+	StatusAdded
+	StatusDeleted
+	StatusRenamed
+	StatusBinary
+)
+
+// Statuses lists every status in display order.
+var Statuses = []Status{StatusAdded, StatusModified, StatusDeleted, StatusRenamed, StatusBinary}
+
+func (s Status) String() string {
+	switch s {
+	case StatusAdded:
+		return "added"
+	case StatusDeleted:
+		return "deleted"
+	case StatusRenamed:
+		return "renamed"
+	case StatusBinary:
+		return "binary"
+	default:
+		return "modified"
+	}
+}
+
+// ParseStatus returns the status with the given name.
+func ParseStatus(name string) (Status, bool) {
+	for _, s := range Statuses {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return StatusModified, false
+}
+
+// GetFileStatus classifies a file. Renames and copies only count as
+// StatusRenamed when their content is unchanged, so that renamed files with
+// real edits are treated as modified.
+func GetFileStatus(file *gitdiff.File) Status {
+	switch {
+	case file.IsBinary:
+		return StatusBinary
+	case file.IsNew:
+		return StatusAdded
+	case file.IsDelete:
+		return StatusDeleted
+	case (file.IsRename || file.IsCopy) && len(file.TextFragments) == 0:
+		return StatusRenamed
+	default:
+		return StatusModified
+	}
+}
+
+// StatusColor returns the color used for files with the given status.
+func StatusColor(status Status) color.Color {
+	switch status {
+	case StatusAdded:
+		return KindAdded.Color()
+	case StatusDeleted:
+		return KindDeleted.Color()
+	case StatusRenamed:
+		return KindRenamed.Color()
+	case StatusBinary:
+		return KindBinary.Color()
+	default:
+		return KindModified.Color()
+	}
+}
+
+// ChangeKind is how a file is displayed in the tree. Unlike Status, a
+// renamed file with edits is still shown as a rename.
+type ChangeKind int
+
+const (
+	KindModified ChangeKind = iota
+	KindAdded
+	KindDeleted
+	KindRenamed
+	KindCopied
+	KindBinary
+	KindModeChanged
+)
+
+// GetChangeKind returns the display kind of a file.
+func GetChangeKind(file *gitdiff.File) ChangeKind {
+	switch {
+	case file.IsNew:
+		return KindAdded
+	case file.IsDelete:
+		return KindDeleted
+	case file.IsRename:
+		return KindRenamed
+	case file.IsCopy:
+		return KindCopied
+	case file.IsBinary:
+		return KindBinary
+	case IsModeChange(file) && len(file.TextFragments) == 0:
+		return KindModeChanged
+	default:
+		return KindModified
+	}
+}
+
+// Color returns the color of files of this kind.
+func (k ChangeKind) Color() color.Color {
+	t := theme.Current()
+	switch k {
+	case KindAdded:
+		return t.Added
+	case KindDeleted:
+		return t.Deleted
+	case KindRenamed:
+		return t.Renamed
+	case KindCopied:
+		return t.Copied
+	case KindBinary:
+		return t.Binary
+	case KindModeChanged:
+		return t.ModeChanged
+	default:
+		return t.Modified
+	}
+}
+
+// Icon returns the status icon of this kind for the given icon style. All
+// nerd font styles share the boxed status icons.
+func (k ChangeKind) Icon(iconStyle string) string {
+	switch iconStyle {
+	case IconsUnicode:
+		return [...]string{"●", "+", "⛌", "→", "⧉", "◆", "⚙"}[k]
+	case IconsNerdStatus, IconsNerdSimple, IconsNerdFiletype, IconsNerdFull:
+		return [...]string{
+			"\uf459", // modified
+			"\uf457", // added
+			"\ueadf", // deleted
+			"\uf45a", // renamed
+			"\uf0c5", // copied
+			"\uf471", // binary
+			"\uf013", // mode changed
+		}[k]
+	default: // ascii
+		return [...]string{"*", "+", "x", ">", "=", "#", "~"}[k]
+	}
+}
+
+// IsModeChange returns whether the file's mode changed, e.g. it became executable.
+func IsModeChange(file *gitdiff.File) bool {
+	return file.OldMode != 0 && file.NewMode != 0 && file.OldMode != file.NewMode
+}
+
+// FormatMode formats a mode the way git does, e.g. 100644.
+func FormatMode(mode os.FileMode) string {
+	return fmt.Sprintf("%06o", uint32(mode))
+}
@@ -0,0 +1,83 @@
+package filenode
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+)
+
+func TestGetChangeKind(t *testing.T) {
+	tests := []struct {
+		name string
+		file *gitdiff.File
+		want ChangeKind
+	}{
+		{"modified", &gitdiff.File{OldName: "a", NewName: "a", TextFragments: []*gitdiff.TextFragment{{}}}, KindModified},
+		{"added", &gitdiff.File{NewName: "a", IsNew: true}, KindAdded},
+		{"deleted", &gitdiff.File{OldName: "a", IsDelete: true}, KindDeleted},
+		{"renamed", &gitdiff.File{OldName: "a", NewName: "b", IsRename: true}, KindRenamed},
+		{"copied", &gitdiff.File{OldName: "a", NewName: "b", IsCopy: true}, KindCopied},
+		{"binary", &gitdiff.File{OldName: "a", NewName: "a", IsBinary: true}, KindBinary},
+		{"mode change", &gitdiff.File{OldName: "a", NewName: "a", OldMode: 0o100644, NewMode: 0o100755}, KindModeChanged},
+		{"mode change with edits", &gitdiff.File{
+			OldName: "a", NewName: "a", OldMode: 0o100644, NewMode: 0o100755,
+			TextFragments: []*gitdiff.TextFragment{{}},
+		}, KindModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetChangeKind(tt.file); got != tt.want {
+				t.Errorf("GetChangeKind() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModeChangeColor(t *testing.T) {
+	th, err := theme.New(config.ThemeConfig{
+		Name:   theme.Dark,
+		Colors: map[string]string{"modeChanged": "#123456"},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev := theme.Current()
+	theme.Set(th)
+	t.Cleanup(func() { theme.Set(prev) })
+
+	if got := KindModeChanged.Color(); got != th.ModeChanged || got == th.Modified {
+		t.Errorf("KindModeChanged.Color() = %v, want the mode change color %v", got, th.ModeChanged)
+	}
+}
+
+func TestDisplayNameShowsRenames(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     *gitdiff.File
+		fullPath bool
+		want     string
+	}{
+		{"same dir", &gitdiff.File{OldName: "pkg/a.go", NewName: "pkg/b.go", IsRename: true}, false, "a.go → b.go"},
+		{"moved", &gitdiff.File{OldName: "old/a.go", NewName: "pkg/a.go", IsRename: true}, false, "old/a.go → a.go"},
+		{"flat", &gitdiff.File{OldName: "pkg/a.go", NewName: "pkg/b.go", IsCopy: true}, true, "pkg/a.go → pkg/b.go"},
+		{"plain", &gitdiff.File{OldName: "pkg/a.go", NewName: "pkg/a.go"}, false, "a.go"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &FileNode{File: tt.file, ShowFullPath: tt.fullPath}
+			if got := f.displayName(); got != tt.want {
+				t.Errorf("displayName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMode(t *testing.T) {
+	if got := FormatMode(os.FileMode(0o100755)); got != "100755" {
+		t.Errorf("FormatMode() = %q, want %q", got, "100755")
+	}
+}
@@ -0,0 +1,179 @@
+// Package filesort orders the files of a diff for display in the file tree.
+package filesort
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/constants"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// Sort mode constants.
+const (
+	ModeTree      = "tree"
+	ModePath      = "path"
+	ModeChurn     = "churn"
+	ModeAdditions = "additions"
+	ModeDeletions = "deletions"
+	ModeStatus    = "status"
+	ModeExtension = "extension"
+)
+
+// Modes lists the sort modes in the order they are cycled through.
+var Modes = []string{
+	ModeTree,
+	ModePath,
+	ModeChurn,
+	ModeAdditions,
+	ModeDeletions,
+	ModeStatus,
+	ModeExtension,
+}
+
+// Next returns the sort mode that follows mode when cycling.
+func Next(mode string) string {
+	i := slices.Index(Modes, mode)
+	return Modes[(i+1)%len(Modes)]
+}
+
+// Sort orders files in place. Files matching the review order rules are
+// moved to the front or the back first, then mode decides the order within
+// each group. Unknown modes fall back to ModeTree.
+func Sort(files []*gitdiff.File, mode string, order config.ReviewOrderConfig) {
+	cmp := comparator(mode)
+	slices.SortStableFunc(files, func(a, b *gitdiff.File) int {
+		if r := reviewRank(a, order) - reviewRank(b, order); r != 0 {
+			return r
+		}
+		if c := cmp(a, b); c != 0 {
+			return c
+		}
+		return compareTree(a, b)
+	})
+}
+
+func comparator(mode string) func(a, b *gitdiff.File) int {
+	switch mode {
+	case ModePath:
+		return comparePath
+	case ModeChurn:
+		return func(a, b *gitdiff.File) int {
+			return compareDesc(churn(a), churn(b))
+		}
+	case ModeAdditions:
+		return func(a, b *gitdiff.File) int {
+			aa, _ := filenode.DiffStats(a)
+			ba, _ := filenode.DiffStats(b)
+			return compareDesc(aa, ba)
+		}
+	case ModeDeletions:
+		return func(a, b *gitdiff.File) int {
+			_, ad := filenode.DiffStats(a)
+			_, bd := filenode.DiffStats(b)
+			return compareDesc(ad, bd)
+		}
+	case ModeStatus:
+		return func(a, b *gitdiff.File) int {
+			return statusRank(a) - statusRank(b)
+		}
+	case ModeExtension:
+		return func(a, b *gitdiff.File) int {
+			extA := strings.ToLower(filepath.Ext(filenode.GetFileName(a)))
+			extB := strings.ToLower(filepath.Ext(filenode.GetFileName(b)))
+			if c := strings.Compare(extA, extB); c != 0 {
+				return c
+			}
+			return comparePath(a, b)
+		}
+	default:
+		return compareTree
+	}
+}
+
+// reviewRank places files matching a "first" rule before everything else
+// and files matching a "last" rule after everything else. Earlier rules win
+// over later ones.
+func reviewRank(file *gitdiff.File, order config.ReviewOrderConfig) int {
+	name := filenode.GetFileName(file)
+	for i, pattern := range order.First {
+		if utils.MatchGlob(pattern, name) {
+			return i - len(order.First)
+		}
+	}
+	for i, pattern := range order.Last {
+		if utils.MatchGlob(pattern, name) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func churn(file *gitdiff.File) int64 {
+	added, deleted := filenode.DiffStats(file)
+	return added + deleted
+}
+
+func statusRank(file *gitdiff.File) int {
+	switch {
+	case file.IsNew:
+		return 0
+	case file.IsDelete:
+		return 2
+	default:
+		return 1
+	}
+}
+
+func compareDesc(a, b int64) int {
+	switch {
+	case a > b:
+		return -1
+	case a < b:
+		return 1
+	}
+	return 0
+}
+
+func comparePath(a, b *gitdiff.File) int {
+	return strings.Compare(
+		strings.ToLower(filenode.GetFileName(a)),
+		strings.ToLower(filenode.GetFileName(b)),
+	)
+}
+
+// compareTree orders files directory-first and case-insensitively, which
+// mirrors how the file tree is laid out.
+func compareTree(a *gitdiff.File, b *gitdiff.File) int {
+	nameA := filenode.GetFileName(a)
+	nameB := filenode.GetFileName(b)
+	dira := filepath.Dir(nameA)
+	dirb := filepath.Dir(nameB)
+	if dira != constants.RootName && dirb != constants.RootName && dira == dirb {
+		return strings.Compare(strings.ToLower(nameA), strings.ToLower(nameB))
+	}
+
+	if dira != constants.RootName && dirb == constants.RootName {
+		return -1
+	}
+	if dirb != constants.RootName && dira == constants.RootName {
+		return 1
+	}
+
+	if dira != constants.RootName && dirb != constants.RootName {
+		if strings.HasPrefix(dira, dirb) {
+			return -1
+		}
+
+		if strings.HasPrefix(dirb, dira) {
+			return 1
+		}
+	}
+
+	return strings.Compare(strings.ToLower(nameA), strings.ToLower(nameB))
+}
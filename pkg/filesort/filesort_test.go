@@ -0,0 +1,88 @@
+package filesort
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+)
+
+func newFile(name string, added, deleted int64) *gitdiff.File {
+	return &gitdiff.File{
+		OldName: name,
+		NewName: name,
+		TextFragments: []*gitdiff.TextFragment{
+			{LinesAdded: added, LinesDeleted: deleted},
+		},
+	}
+}
+
+func names(files []*gitdiff.File) []string {
+	res := make([]string, 0, len(files))
+	for _, f := range files {
+		res = append(res, filenode.GetFileName(f))
+	}
+	return res
+}
+
+func TestSortByChurn(t *testing.T) {
+	files := []*gitdiff.File{
+		newFile("a.go", 1, 1),
+		newFile("b.go", 10, 5),
+		newFile("c.go", 3, 0),
+	}
+
+	Sort(files, ModeChurn, config.ReviewOrderConfig{})
+
+	expected := []string{"b.go", "c.go", "a.go"}
+	if got := names(files); !slices.Equal(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSortByStatus(t *testing.T) {
+	deleted := newFile("a.go", 0, 3)
+	deleted.IsDelete = true
+	added := newFile("c.go", 3, 0)
+	added.IsNew = true
+	files := []*gitdiff.File{deleted, newFile("b.go", 1, 1), added}
+
+	Sort(files, ModeStatus, config.ReviewOrderConfig{})
+
+	expected := []string{"c.go", "b.go", "a.go"}
+	if got := names(files); !slices.Equal(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSortReviewOrder(t *testing.T) {
+	files := []*gitdiff.File{
+		newFile("README.md", 1, 0),
+		newFile("pkg/ui/tui_test.go", 50, 0),
+		newFile("pkg/ui/tui.go", 10, 0),
+		newFile("go.mod", 1, 1),
+	}
+	order := config.ReviewOrderConfig{
+		First: []string{"go.mod"},
+		Last:  []string{"*_test.go", "*.md"},
+	}
+
+	Sort(files, ModeChurn, order)
+
+	expected := []string{"go.mod", "pkg/ui/tui.go", "pkg/ui/tui_test.go", "README.md"}
+	if got := names(files); !slices.Equal(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestNextWrapsAround(t *testing.T) {
+	if got := Next(ModeExtension); got != ModeTree {
+		t.Fatalf("expected %q after %q, got %q", ModeTree, ModeExtension, got)
+	}
+	if got := Next("unknown"); got != ModeTree {
+		t.Fatalf("expected unknown mode to cycle to %q, got %q", ModeTree, got)
+	}
+}
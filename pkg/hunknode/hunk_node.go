@@ -0,0 +1,125 @@
+// Package hunknode renders the hunks of an expanded file in the file tree.
+package hunknode
+
+import (
+	"fmt"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"charm.land/lipgloss/v2/tree"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// HunkNode is a single text fragment of a file, listed right below the file
+// when it is expanded.
+type HunkNode struct {
+	File       *gitdiff.File
+	Fragment   *gitdiff.TextFragment
+	Index      int
+	Depth      int
+	PanelWidth int
+	Cfg        config.Config
+}
+
+// Path returns the path of the file the hunk belongs to.
+func (h *HunkNode) Path() string {
+	return filenode.GetFileName(h.File)
+}
+
+// Label returns the hunk's function context, or its position in the file
+// when git found none.
+func (h *HunkNode) Label() string {
+	if comment := strings.TrimSpace(h.Fragment.Comment); comment != "" {
+		return comment
+	}
+	return fmt.Sprintf("hunk %d/%d", h.Index+1, len(h.File.TextFragments))
+}
+
+// LineRange returns the lines the hunk covers in the new file, or in the old
+// file for deletions.
+func (h *HunkNode) LineRange() string {
+	start, lines := h.Fragment.NewPosition, h.Fragment.NewLines
+	if h.File.IsDelete {
+		start, lines = h.Fragment.OldPosition, h.Fragment.OldLines
+	}
+	if lines <= 1 {
+		return fmt.Sprintf("L%d", start)
+	}
+	return fmt.Sprintf("L%d-%d", start, start+lines-1)
+}
+
+// Value renders: [indent arrow] [line range] [label] [stats]
+func (h *HunkNode) Value() string {
+	dim := lipgloss.NewStyle().Foreground(theme.Current().Muted)
+	prefix := "  " + h.getIcon() + " "
+	lineRange := h.LineRange() + " "
+
+	stats := ""
+	if h.Cfg.UI.ShowDiffStats {
+		stats = " " + filenode.ViewDiffStats(h.Fragment.LinesAdded, h.Fragment.LinesDeleted, lipgloss.NewStyle())
+	}
+
+	labelMaxWidth := h.PanelWidth - h.Depth - 1 - lipgloss.Width(prefix) -
+		lipgloss.Width(lineRange) - lipgloss.Width(stats)
+	label := utils.TruncateString(h.Label(), labelMaxWidth)
+
+	return utils.RemoveReset(dim.Render(prefix+lineRange) + label + stats)
+}
+
+func (h *HunkNode) getIcon() string {
+	if h.Cfg.UI.Icons == filenode.IconsASCII {
+		return "-"
+	}
+	return "↳"
+}
+
+func (h *HunkNode) String() string {
+	return h.Value()
+}
+
+func (h *HunkNode) Children() tree.Children {
+	return tree.NodeChildren(nil)
+}
+
+func (h *HunkNode) Hidden() bool {
+	return false
+}
+
+func (h *HunkNode) SetHidden(bool) {}
+
+func (h *HunkNode) SetValue(any) {}
+
+// FirstChange returns the line and column of the hunk's first added or
+// removed line in the new file.
+func FirstChange(frag *gitdiff.TextFragment) (int, int) {
+	for i, line := range frag.Lines {
+		if line.Op != gitdiff.OpContext {
+			return Position(frag, i)
+		}
+	}
+	return Position(frag, 0)
+}
+
+// Position returns the line of the new file at the ith line of the hunk,
+// where removed lines are at the line following them, and the column of its
+// first non-blank character.
+func Position(frag *gitdiff.TextFragment, i int) (int, int) {
+	line := frag.NewPosition
+	i = min(i, len(frag.Lines)-1)
+	if i < 0 {
+		return int(max(line, 1)), 1
+	}
+	for _, l := range frag.Lines[:i] {
+		if l.Op != gitdiff.OpDelete {
+			line++
+		}
+	}
+	text := frag.Lines[i].Line
+	column := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+	return int(max(line, 1)), column
+}
@@ -0,0 +1,39 @@
+package hunknode
+
+import (
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+func TestPosition(t *testing.T) {
+	frag := &gitdiff.TextFragment{
+		NewPosition: 10,
+		Lines: []gitdiff.Line{
+			{Op: gitdiff.OpContext, Line: "func f() {\n"},
+			{Op: gitdiff.OpDelete, Line: "\treturn 1\n"},
+			{Op: gitdiff.OpAdd, Line: "\t\treturn 2\n"},
+			{Op: gitdiff.OpContext, Line: "}\n"},
+		},
+	}
+	tests := []struct {
+		i         int
+		line, col int
+	}{
+		{i: 0, line: 10, col: 1},
+		{i: 1, line: 11, col: 2},
+		{i: 2, line: 11, col: 3},
+		{i: 3, line: 12, col: 1},
+		{i: 10, line: 12, col: 1},
+	}
+	for _, tt := range tests {
+		line, col := Position(frag, tt.i)
+		if line != tt.line || col != tt.col {
+			t.Errorf("Position(%d) = %d:%d, want %d:%d", tt.i, line, col, tt.line, tt.col)
+		}
+	}
+
+	if line, col := FirstChange(frag); line != 11 || col != 2 {
+		t.Errorf("FirstChange() = %d:%d, want 11:2", line, col)
+	}
+}
@@ -0,0 +1,64 @@
+package outline
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// LoadBlobs reads the file before and after the change from the repository at
+// root, using the object ids of the patch's index line. When the new blob
+// isn't in the repository, e.g. for uncommitted changes, the working tree copy
+// is used as long as it still matches the patch.
+func LoadBlobs(file *gitdiff.File, root string) Blobs {
+	var blobs Blobs
+	if !file.IsNew && isObjectID(file.OldOIDPrefix) {
+		if src, err := utils.GitBlob(root, file.OldOIDPrefix); err == nil {
+			blobs.Old = src
+		}
+	}
+	if file.IsDelete {
+		return blobs
+	}
+	if isObjectID(file.NewOIDPrefix) {
+		if src, err := utils.GitBlob(root, file.NewOIDPrefix); err == nil {
+			blobs.New = src
+			return blobs
+		}
+	}
+	if src, err := os.ReadFile(filepath.Join(root, file.NewName)); err == nil && matchesNewSide(file, src) {
+		blobs.New = src
+	}
+	return blobs
+}
+
+// isObjectID returns whether oid names a blob, which the all-zero id of
+// added and deleted files doesn't.
+func isObjectID(oid string) bool {
+	return oid != "" && strings.Trim(oid, "0") != ""
+}
+
+// matchesNewSide returns whether src has the context and added lines of every
+// hunk at their new positions.
+func matchesNewSide(file *gitdiff.File, src []byte) bool {
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	for _, frag := range file.TextFragments {
+		n := int(frag.NewPosition) - 1
+		for _, line := range frag.Lines {
+			if line.Op == gitdiff.OpDelete {
+				continue
+			}
+			if n < 0 || n >= len(lines) ||
+				strings.TrimRight(string(lines[n]), "\r\n") != strings.TrimRight(line.Line, "\r\n") {
+				return false
+			}
+			n++
+		}
+	}
+	return true
+}
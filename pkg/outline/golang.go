@@ -0,0 +1,105 @@
+package outline
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+)
+
+func init() {
+	Register(".go", GoProvider{})
+}
+
+var (
+	goFuncLine = regexp.MustCompile(`^func\s*(?:\(\s*(?:\w+\s+)?\*?\s*(\w+)[^)]*\)\s*)?(\w+)\s*[\[(]`)
+	goTypeLine = regexp.MustCompile(`^type\s+(\w+)\b`)
+)
+
+// GoProvider finds the functions, methods and types of Go files.
+type GoProvider struct{}
+
+// Declarations parses src with go/parser. Files with syntax errors are parsed
+// as far as possible.
+func (GoProvider) Declarations(src []byte) ([]Decl, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if f == nil {
+		return nil, err
+	}
+
+	line := func(pos token.Pos) int {
+		return fset.Position(pos).Line
+	}
+	var decls []Decl
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			start := d.Pos()
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			kind, name := KindFunc, d.Name.Name
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				kind = KindMethod
+				if recv := receiverName(d.Recv.List[0].Type); recv != "" {
+					name = recv + "." + name
+				}
+			}
+			decls = append(decls, Decl{Name: name, Kind: kind, Start: line(start), End: line(d.End())})
+
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				start, end := ts.Pos(), ts.End()
+				if ts.Doc != nil {
+					start = ts.Doc.Pos()
+				}
+				// A single type without parentheses covers the whole declaration.
+				if !d.Lparen.IsValid() {
+					start, end = d.Pos(), d.End()
+					if d.Doc != nil {
+						start = d.Doc.Pos()
+					}
+				}
+				decls = append(decls, Decl{Name: ts.Name.Name, Kind: KindType, Start: line(start), End: line(end)})
+			}
+		}
+	}
+	return decls, nil
+}
+
+// LineDeclaration recognizes lines starting a function, method or type.
+func (GoProvider) LineDeclaration(line string) (Decl, bool) {
+	if m := goFuncLine.FindStringSubmatch(line); m != nil {
+		if m[1] != "" {
+			return Decl{Name: m[1] + "." + m[2], Kind: KindMethod}, true
+		}
+		return Decl{Name: m[2], Kind: KindFunc}, true
+	}
+	if m := goTypeLine.FindStringSubmatch(line); m != nil {
+		return Decl{Name: m[1], Kind: KindType}, true
+	}
+	return Decl{}, false
+}
+
+// receiverName returns the type name of a method receiver, without pointers
+// and type parameters.
+func receiverName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(e.X)
+	case *ast.IndexExpr:
+		return receiverName(e.X)
+	case *ast.IndexListExpr:
+		return receiverName(e.X)
+	case *ast.ParenExpr:
+		return receiverName(e.X)
+	case *ast.Ident:
+		return e.Name
+	}
+	return ""
+}
@@ -0,0 +1,297 @@
+// Package outline lists the symbols, such as functions and types, that the
+// hunks of a file touch. Languages are supported through Providers.
+package outline
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+)
+
+// Kind is the kind of a declaration.
+type Kind int
+
+const (
+	KindFunc Kind = iota
+	KindMethod
+	KindType
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindMethod:
+		return "method"
+	case KindType:
+		return "type"
+	default:
+		return "func"
+	}
+}
+
+// Change is how a symbol was changed by the diff.
+type Change int
+
+const (
+	Modified Change = iota
+	Added
+	Removed
+)
+
+func (c Change) String() string {
+	switch c {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+// Decl is a declaration found in a source file. Start and End are 1-based
+// line numbers.
+type Decl struct {
+	Name  string
+	Kind  Kind
+	Start int
+	End   int
+}
+
+// Provider finds declarations for a language.
+type Provider interface {
+	// Declarations parses a complete source file.
+	Declarations(src []byte) ([]Decl, error)
+	// LineDeclaration finds a declaration starting on a single line. It is
+	// used on the hunk text when the file's blobs aren't available.
+	LineDeclaration(line string) (Decl, bool)
+}
+
+// Symbol is a declaration touched by a hunk.
+type Symbol struct {
+	Decl
+	Change Change
+	// Hunk is the index of the text fragment that touches the symbol.
+	Hunk int
+}
+
+var errMissing = errors.New("blob not available")
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{}
+)
+
+// Register makes a provider available for files with the given extension,
+// e.g. ".go".
+func Register(ext string, p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[ext] = p
+}
+
+// ProviderFor returns the provider for the file at path, if there is one.
+func ProviderFor(path string) (Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[filepath.Ext(path)]
+	return p, ok
+}
+
+// Supports returns whether an outline can be built for the file.
+func Supports(file *gitdiff.File) bool {
+	if file.IsBinary {
+		return false
+	}
+	_, ok := ProviderFor(filenode.GetFileName(file))
+	return ok
+}
+
+// Blobs are the contents of a file before and after the change. Either may
+// be nil when it isn't available.
+type Blobs struct {
+	Old []byte
+	New []byte
+}
+
+// Symbols returns the symbols touched by each hunk of the file, in hunk order.
+// Both blobs are parsed when available, which tells renamed or rewritten
+// symbols apart from modified ones. Otherwise declarations are looked up in
+// the hunk text only.
+func Symbols(file *gitdiff.File, blobs Blobs) []Symbol {
+	p, ok := ProviderFor(filenode.GetFileName(file))
+	if !ok || file.IsBinary {
+		return nil
+	}
+
+	var oldDecls, newDecls []Decl
+	var oldErr, newErr error = errMissing, errMissing
+	if blobs.Old != nil && !file.IsNew {
+		oldDecls, oldErr = p.Declarations(blobs.Old)
+	}
+	if blobs.New != nil && !file.IsDelete {
+		newDecls, newErr = p.Declarations(blobs.New)
+	}
+	if file.IsNew {
+		oldErr = nil
+	}
+	if file.IsDelete {
+		newErr = nil
+	}
+	if oldErr != nil && newErr != nil {
+		return hunkSymbols(p, file)
+	}
+
+	oldNames := declNames(oldDecls)
+	newNames := declNames(newDecls)
+
+	var symbols []Symbol
+	for i, frag := range file.TextFragments {
+		added, deleted := changedLines(frag)
+		var hunk []Symbol
+		seen := map[string]bool{}
+
+		for _, decl := range newDecls {
+			if !overlaps(decl, added) || seen[declKey(decl)] {
+				continue
+			}
+			seen[declKey(decl)] = true
+			change := Modified
+			switch {
+			case oldErr == nil && !oldNames[declKey(decl)]:
+				change = Added
+			case oldErr != nil && slices.Contains(added, decl.Start):
+				change = Added
+			}
+			hunk = append(hunk, Symbol{Decl: decl, Change: change, Hunk: i})
+		}
+		for _, decl := range oldDecls {
+			if !overlaps(decl, deleted) || seen[declKey(decl)] {
+				continue
+			}
+			seen[declKey(decl)] = true
+			change := Modified
+			switch {
+			case newErr == nil && !newNames[declKey(decl)]:
+				change = Removed
+			case newErr != nil && slices.Contains(deleted, decl.Start):
+				change = Removed
+			}
+			hunk = append(hunk, Symbol{Decl: decl, Change: change, Hunk: i})
+		}
+
+		slices.SortStableFunc(hunk, func(a, b Symbol) int { return a.Start - b.Start })
+		symbols = append(symbols, hunk...)
+	}
+	return symbols
+}
+
+// hunkSymbols finds the declarations in the hunk text. Declarations starting
+// on an added or deleted line are added or removed, and other changed lines
+// modify the declaration they follow, starting with the one named in the hunk
+// header.
+func hunkSymbols(p Provider, file *gitdiff.File) []Symbol {
+	var symbols []Symbol
+	for i, frag := range file.TextFragments {
+		var hunk []Symbol
+		index := map[string]int{}
+		add := func(decl Decl, change Change) {
+			key := declKey(decl)
+			j, ok := index[key]
+			if !ok {
+				index[key] = len(hunk)
+				hunk = append(hunk, Symbol{Decl: decl, Change: change, Hunk: i})
+				return
+			}
+			// A declaration both removed and added, e.g. with a new signature.
+			if change != Modified && hunk[j].Change != Modified && hunk[j].Change != change {
+				hunk[j].Change = Modified
+			}
+		}
+
+		current, hasCurrent := p.LineDeclaration(frag.Comment)
+		oldLine, newLine := int(frag.OldPosition), int(frag.NewPosition)
+		for _, line := range frag.Lines {
+			decl, isDecl := p.LineDeclaration(line.Line)
+			switch line.Op {
+			case gitdiff.OpAdd:
+				decl.Start = newLine
+				newLine++
+			case gitdiff.OpDelete:
+				decl.Start = oldLine
+				oldLine++
+			default:
+				decl.Start = newLine
+				oldLine++
+				newLine++
+			}
+			if isDecl {
+				current, hasCurrent = decl, true
+			}
+
+			switch {
+			case line.Op == gitdiff.OpContext:
+			case isDecl && line.Op == gitdiff.OpAdd:
+				add(decl, Added)
+			case isDecl:
+				add(decl, Removed)
+			case hasCurrent && strings.TrimSpace(line.Line) != "":
+				add(current, Modified)
+			}
+		}
+		symbols = append(symbols, hunk...)
+	}
+	return symbols
+}
+
+// changedLines returns the new line numbers of the added lines and the old
+// line numbers of the deleted lines of a hunk.
+func changedLines(frag *gitdiff.TextFragment) ([]int, []int) {
+	var added, deleted []int
+	oldLine, newLine := int(frag.OldPosition), int(frag.NewPosition)
+	for _, line := range frag.Lines {
+		switch line.Op {
+		case gitdiff.OpAdd:
+			added = append(added, newLine)
+			newLine++
+		case gitdiff.OpDelete:
+			deleted = append(deleted, oldLine)
+			oldLine++
+		default:
+			oldLine++
+			newLine++
+		}
+	}
+	return added, deleted
+}
+
+func overlaps(decl Decl, lines []int) bool {
+	for _, line := range lines {
+		if line >= decl.Start && line <= decl.End {
+			return true
+		}
+	}
+	return false
+}
+
+func declKey(decl Decl) string {
+	return decl.Kind.String() + " " + decl.Name
+}
+
+func declNames(decls []Decl) map[string]bool {
+	names := make(map[string]bool, len(decls))
+	for _, decl := range decls {
+		names[declKey(decl)] = true
+	}
+	return names
+}
+
+// Label renders the symbol as e.g. "func Foo" or "method Model.Update".
+func (s Symbol) Label() string {
+	return strings.TrimSpace(s.Kind.String() + " " + s.Name)
+}
@@ -0,0 +1,138 @@
+package outline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+const oldSrc = `package foo
+
+func Keep() int {
+	return 1
+}
+
+func Gone() {}
+
+type T struct{}
+
+func (t *T) M() {}
+
+// Padding keeps the hunks apart.
+func Padding() {
+	println("a")
+	println("b")
+	println("c")
+	println("d")
+}
+
+func Last() {}
+`
+
+const newSrc = `package foo
+
+func Keep() int {
+	return 2
+}
+
+type T struct{}
+
+func (t *T) M() {}
+
+// Padding keeps the hunks apart.
+func Padding() {
+	println("a")
+	println("b")
+	println("c")
+	println("d")
+}
+
+func Last() {}
+
+func New() *T {
+	return &T{}
+}
+`
+
+const patch = `diff --git a/foo.go b/foo.go
+index ca0a0ce..bad2353 100644
+--- a/foo.go
++++ b/foo.go
+@@ -3,7 +3,5 @@ package foo
+ func Keep() int {
+-	return 1
++	return 2
+ }
+ 
+-func Gone() {}
+-
+ type T struct{}
+@@ -21 +19,5 @@ func Padding() {
+ func Last() {}
++
++func New() *T {
++	return &T{}
++}
+`
+
+func parsePatch(t *testing.T) *gitdiff.File {
+	t.Helper()
+	files, _, err := gitdiff.Parse(strings.NewReader(patch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files[0]
+}
+
+func labels(symbols []Symbol) []string {
+	var got []string
+	for _, s := range symbols {
+		got = append(got, s.Change.String()+" "+s.Label()+" #"+string(rune('0'+s.Hunk)))
+	}
+	return got
+}
+
+func TestSymbolsFromBlobs(t *testing.T) {
+	file := parsePatch(t)
+	got := labels(Symbols(file, Blobs{Old: []byte(oldSrc), New: []byte(newSrc)}))
+	want := []string{
+		"modified func Keep #0",
+		"removed func Gone #0",
+		"added func New #1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected symbols:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestSymbolsFromHunkText(t *testing.T) {
+	file := parsePatch(t)
+	got := labels(Symbols(file, Blobs{}))
+	want := []string{
+		"modified func Keep #0",
+		"removed func Gone #0",
+		"added func New #1",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expected symbols:\n%s\ngot:\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestGoDeclarations(t *testing.T) {
+	decls, err := GoProvider{}.Declarations([]byte(newSrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range decls {
+		got = append(got, d.Kind.String()+" "+d.Name)
+	}
+	want := "func Keep,type T,method T.M,func Padding,func Last,func New"
+	if strings.Join(got, ",") != want {
+		t.Fatalf("expected %s, got %s", want, strings.Join(got, ","))
+	}
+	if padding := decls[3]; padding.Start != 11 || padding.End != 17 {
+		t.Fatalf("expected Padding to span its doc comment, lines 11-17, got %d-%d", padding.Start, padding.End)
+	}
+}
@@ -0,0 +1,194 @@
+// Package patch builds the text, and the patch, of some of the lines of a
+// file's diff.
+package patch
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// contextLines is how many lines of context are kept around the changes, as
+// git apply needs some to apply a patch.
+const contextLines = 3
+
+// Line is a line of a file's diff: the index of its hunk, and its index in
+// the hunk.
+type Line struct {
+	Hunk  int
+	Index int
+}
+
+// OldText returns the text of the lines in the old file, the context and
+// removed ones, in order.
+func OldText(file *gitdiff.File, lines []Line) string {
+	return text(file, lines, gitdiff.OpDelete)
+}
+
+// NewText returns the text of the lines in the new file, the context and
+// added ones, in order.
+func NewText(file *gitdiff.File, lines []Line) string {
+	return text(file, lines, gitdiff.OpAdd)
+}
+
+func text(file *gitdiff.File, lines []Line, op gitdiff.LineOp) string {
+	var sb strings.Builder
+	for _, l := range sorted(file, lines) {
+		line := file.TextFragments[l.Hunk].Lines[l.Index]
+		if line.Op == gitdiff.OpContext || line.Op == op {
+			sb.WriteString(line.Line)
+		}
+	}
+	return sb.String()
+}
+
+// Select returns the diff of the file holding only the changes of the given
+// lines, which applies to the old file on its own. Within each hunk, the
+// lines from the first given one to the last are kept, along with the
+// context around them, with the removed lines that weren't given turned into
+// context and the added ones dropped. It returns nil if no added or removed
+// line is given.
+func Select(file *gitdiff.File, lines []Line) *gitdiff.File {
+	byHunk := map[int][]int{}
+	for _, l := range sorted(file, lines) {
+		byHunk[l.Hunk] = append(byHunk[l.Hunk], l.Index)
+	}
+
+	var frags []*gitdiff.TextFragment
+	// shift is how many lines the fragments so far add to the new file.
+	var shift int64
+	for i, frag := range file.TextFragments {
+		indexes := byHunk[i]
+		if len(indexes) == 0 {
+			continue
+		}
+		s := selectFragment(frag, indexes, shift)
+		if s == nil {
+			continue
+		}
+		frags = append(frags, s)
+		shift += s.LinesAdded - s.LinesDeleted
+	}
+	if len(frags) == 0 {
+		return nil
+	}
+
+	selected := *file
+	selected.TextFragments = frags
+	// The blobs of a partial patch are neither side's.
+	selected.OldOIDPrefix, selected.NewOIDPrefix = "", ""
+	selected.IsBinary, selected.BinaryFragment, selected.ReverseBinaryFragment = false, nil, nil
+	// A deleted file is only deleted by a patch removing all its lines.
+	if file.IsDelete && deleted(frags) < deleted(file.TextFragments) {
+		selected.IsDelete = false
+		selected.NewName, selected.NewMode = file.OldName, file.OldMode
+	}
+	return &selected
+}
+
+// selectFragment returns the fragment of the hunk from the first of the
+// sorted indexes to the last, holding only their changes, or nil if they
+// have none.
+func selectFragment(frag *gitdiff.TextFragment, indexes []int, shift int64) *gitdiff.TextFragment {
+	first, last := indexes[0], indexes[len(indexes)-1]
+	for n := 0; first > 0 && n < contextLines; {
+		first--
+		if frag.Lines[first].Op != gitdiff.OpAdd {
+			n++
+		}
+	}
+	for n := 0; last < len(frag.Lines)-1 && n < contextLines; {
+		last++
+		if frag.Lines[last].Op != gitdiff.OpAdd {
+			n++
+		}
+	}
+
+	// oldStart is the line of the old file the fragment starts at. Git puts
+	// the line before a hunk removing nothing in its header.
+	oldStart := frag.OldPosition
+	if frag.OldLines == 0 {
+		oldStart++
+	}
+	for _, line := range frag.Lines[:first] {
+		if line.Op != gitdiff.OpAdd {
+			oldStart++
+		}
+	}
+
+	s := &gitdiff.TextFragment{Comment: frag.Comment}
+	next := 0
+	for i := first; i <= last; i++ {
+		line := frag.Lines[i]
+		given := next < len(indexes) && indexes[next] == i
+		if given {
+			next++
+		}
+		switch {
+		case line.Op == gitdiff.OpContext:
+		case given && line.Op == gitdiff.OpAdd:
+			s.LinesAdded++
+		case given && line.Op == gitdiff.OpDelete:
+			s.LinesDeleted++
+		case line.Op == gitdiff.OpAdd:
+			continue
+		default:
+			line.Op = gitdiff.OpContext
+		}
+		s.Lines = append(s.Lines, line)
+	}
+	if s.LinesAdded == 0 && s.LinesDeleted == 0 {
+		return nil
+	}
+
+	changed := false
+	for _, line := range s.Lines {
+		if line.Op != gitdiff.OpAdd {
+			s.OldLines++
+		}
+		if line.Op != gitdiff.OpDelete {
+			s.NewLines++
+		}
+		switch {
+		case line.Op != gitdiff.OpContext:
+			changed = true
+			s.TrailingContext = 0
+		case changed:
+			s.TrailingContext++
+		default:
+			s.LeadingContext++
+		}
+	}
+
+	s.OldPosition, s.NewPosition = oldStart, oldStart+shift
+	if s.OldLines == 0 {
+		s.OldPosition--
+	}
+	if s.NewLines == 0 {
+		s.NewPosition--
+	}
+	return s
+}
+
+func deleted(frags []*gitdiff.TextFragment) int64 {
+	var n int64
+	for _, frag := range frags {
+		n += frag.LinesDeleted
+	}
+	return n
+}
+
+// sorted returns the lines that exist in the file, sorted and without
+// duplicates.
+func sorted(file *gitdiff.File, lines []Line) []Line {
+	res := slices.DeleteFunc(slices.Clone(lines), func(l Line) bool {
+		return l.Hunk < 0 || l.Hunk >= len(file.TextFragments) ||
+			l.Index < 0 || l.Index >= len(file.TextFragments[l.Hunk].Lines)
+	})
+	slices.SortFunc(res, func(a, b Line) int {
+		return cmp.Or(cmp.Compare(a.Hunk, b.Hunk), cmp.Compare(a.Index, b.Index))
+	})
+	return slices.Compact(res)
+}
@@ -0,0 +1,146 @@
+package patch
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+const old = `one
+two
+three
+four
+five
+six
+seven
+eight
+nine
+ten
+`
+
+const diff = `diff --git a/numbers.txt b/numbers.txt
+index 1111111..2222222 100644
+--- a/numbers.txt
++++ b/numbers.txt
+@@ -1,5 +1,6 @@
+ one
+-two
+-three
++2
++3
++3.5
+ four
+ five
+@@ -8,3 +9,3 @@ seven
+ eight
+-nine
++9
+ ten
+`
+
+func parse(t *testing.T, s string) *gitdiff.File {
+	t.Helper()
+	files, _, err := gitdiff.Parse(strings.NewReader(s))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("failed to parse the diff: %v", err)
+	}
+	return files[0]
+}
+
+func TestText(t *testing.T) {
+	file := parse(t, diff)
+	lines := []Line{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}}
+	if got, want := OldText(file, lines), "one\ntwo\nthree\n"; got != want {
+		t.Errorf("OldText() = %q, want %q", got, want)
+	}
+	if got, want := NewText(file, lines), "one\n2\n3\n"; got != want {
+		t.Errorf("NewText() = %q, want %q", got, want)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	file := parse(t, diff)
+	tests := []struct {
+		name  string
+		lines []Line
+		want  string
+	}{
+		{
+			name:  "whole hunk",
+			lines: []Line{{0, 0}, {0, 1}, {0, 2}, {0, 3}, {0, 4}, {0, 5}, {0, 6}, {0, 7}},
+			want:  "one\n2\n3\n3.5\nfour\nfive\nsix\nseven\neight\nnine\nten\n",
+		},
+		{
+			name:  "one removed and one added line",
+			lines: []Line{{0, 2}, {0, 3}},
+			want:  "one\ntwo\n2\nfour\nfive\nsix\nseven\neight\nnine\nten\n",
+		},
+		{
+			name:  "added line only",
+			lines: []Line{{0, 5}},
+			want:  "one\ntwo\nthree\n3.5\nfour\nfive\nsix\nseven\neight\nnine\nten\n",
+		},
+		{
+			name:  "across hunks",
+			lines: []Line{{0, 4}, {0, 5}, {0, 6}, {1, 0}, {1, 1}, {1, 2}},
+			want:  "one\ntwo\nthree\n3\n3.5\nfour\nfive\nsix\nseven\neight\n9\nten\n",
+		},
+		{
+			name:  "removed line only",
+			lines: []Line{{1, 1}},
+			want:  "one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nten\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := Select(file, tt.lines)
+			if selected == nil {
+				t.Fatal("expected a patch")
+			}
+			for _, frag := range selected.TextFragments {
+				if err := frag.Validate(); err != nil {
+					t.Fatalf("invalid fragment %s: %v", frag.Header(), err)
+				}
+			}
+
+			// The patch must survive being copied as text.
+			reparsed := parse(t, selected.String())
+			var out bytes.Buffer
+			if err := gitdiff.Apply(&out, strings.NewReader(old), reparsed); err != nil {
+				t.Fatalf("failed to apply the patch:\n%s\n%v", selected, err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("applying the patch gives %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+
+	if got := Select(file, []Line{{0, 0}, {0, 6}}); got != nil {
+		t.Errorf("expected no patch for context lines, got:\n%s", got)
+	}
+}
+
+func TestSelectPartialDeletion(t *testing.T) {
+	file := parse(t, `diff --git a/gone.txt b/gone.txt
+deleted file mode 100644
+index 1111111..0000000
+--- a/gone.txt
++++ /dev/null
+@@ -1,2 +0,0 @@
+-a
+-b
+`)
+	selected := Select(file, []Line{{0, 1}})
+	if selected.IsDelete || selected.NewName != "gone.txt" {
+		t.Fatalf("expected removing part of a deleted file to modify it, got:\n%s", selected)
+	}
+	var out bytes.Buffer
+	if err := gitdiff.Apply(&out, strings.NewReader("a\nb\n"), parse(t, selected.String())); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "a\n" {
+		t.Errorf("applying the patch gives %q, want %q", out.String(), "a\n")
+	}
+}
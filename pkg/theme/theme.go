@@ -0,0 +1,232 @@
+// Package theme holds the named colors used across the UI and the built-in
+// themes they come from.
+package theme
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+)
+
+// Theme names.
+const (
+	Auto         = "auto"
+	Dark         = "dark"
+	Light        = "light"
+	HighContrast = "high-contrast"
+)
+
+// Theme maps the semantic colors of the UI to terminal colors.
+type Theme struct {
+	Name string
+
+	// Selection is the background of the selected node and the file headers
+	// of directory diffs. It's also used for the tree guides.
+	Selection color.Color
+	// Bar is the background of the footer.
+	Bar color.Color
+	// Cursor is the background of the cursor in lists such as the search
+	// results and the outline.
+	Cursor color.Color
+
+	Text      color.Color // file names when they aren't colored by status
+	Muted     color.Color // borders of inactive panes, hints and viewed files
+	Accent    color.Color // the active pane, help keys and the diff header
+	Directory color.Color // directories in the file tree and the outline
+	Info      color.Color // the title, owners and other extra information
+
+	Added    color.Color
+	Deleted  color.Color
+	Modified color.Color
+	Renamed  color.Color
+	Copied   color.Color
+	Binary   color.Color
+	// ModeChanged is the color of files whose only change is their mode.
+	ModeChanged color.Color
+	// Marked is the icon color of files selected for a combined diff.
+	Marked color.Color
+	// Churn fills the bar showing each directory's share of the changes.
+	Churn color.Color
+}
+
+var themes = map[string]Theme{
+	Dark: {
+		Name:        Dark,
+		Selection:   lipgloss.Color("#2d2c35"),
+		Bar:         lipgloss.Color("#201f26"),
+		Cursor:      lipgloss.Color("#1b1b33"),
+		Text:        lipgloss.BrightWhite,
+		Muted:       lipgloss.BrightBlack,
+		Accent:      lipgloss.Blue,
+		Directory:   lipgloss.BrightBlue,
+		Info:        lipgloss.Cyan,
+		Added:       lipgloss.Green,
+		Deleted:     lipgloss.Red,
+		Modified:    lipgloss.Yellow,
+		Renamed:     lipgloss.Blue,
+		Copied:      lipgloss.Cyan,
+		Binary:      lipgloss.Magenta,
+		ModeChanged: lipgloss.Color("208"),
+		Marked:      lipgloss.Magenta,
+		Churn:       lipgloss.Yellow,
+	},
+	Light: {
+		Name:        Light,
+		Selection:   lipgloss.Color("#dcdce4"),
+		Bar:         lipgloss.Color("#e9e9ef"),
+		Cursor:      lipgloss.Color("#cdd6f4"),
+		Text:        lipgloss.Color("#1f1f28"),
+		Muted:       lipgloss.Color("#6c6f85"),
+		Accent:      lipgloss.Color("#1e66f5"),
+		Directory:   lipgloss.Color("#1e66f5"),
+		Info:        lipgloss.Color("#0b7285"),
+		Added:       lipgloss.Color("#2b8a3e"),
+		Deleted:     lipgloss.Color("#c92a2a"),
+		Modified:    lipgloss.Color("#a15c00"),
+		Renamed:     lipgloss.Color("#1e66f5"),
+		Copied:      lipgloss.Color("#0b7285"),
+		Binary:      lipgloss.Color("#8839ef"),
+		ModeChanged: lipgloss.Color("#d9480f"),
+		Marked:      lipgloss.Color("#8839ef"),
+		Churn:       lipgloss.Color("#a15c00"),
+	},
+	HighContrast: {
+		Name:        HighContrast,
+		Selection:   lipgloss.Color("#3a3a3a"),
+		Bar:         lipgloss.Color("#000000"),
+		Cursor:      lipgloss.Color("#005f87"),
+		Text:        lipgloss.Color("#ffffff"),
+		Muted:       lipgloss.Color("#bcbcbc"),
+		Accent:      lipgloss.Color("#5fafff"),
+		Directory:   lipgloss.Color("#87d7ff"),
+		Info:        lipgloss.Color("#00ffff"),
+		Added:       lipgloss.Color("#00ff00"),
+		Deleted:     lipgloss.Color("#ff5f5f"),
+		Modified:    lipgloss.Color("#ffff00"),
+		Renamed:     lipgloss.Color("#5fafff"),
+		Copied:      lipgloss.Color("#00ffff"),
+		Binary:      lipgloss.Color("#ff87ff"),
+		ModeChanged: lipgloss.Color("#ffaf00"),
+		Marked:      lipgloss.Color("#ff87ff"),
+		Churn:       lipgloss.Color("#ffff00"),
+	},
+}
+
+// current is the theme in use. It's set on startup, before the UI renders
+// anything, and by the UI when the config is reloaded.
+var current = themes[Dark]
+
+// Current returns the theme in use.
+func Current() Theme {
+	return current
+}
+
+// Set makes t the theme in use.
+func Set(t Theme) {
+	current = t
+}
+
+// Names returns the names of the built-in themes.
+func Names() []string {
+	return []string{Auto, Dark, Light, HighContrast}
+}
+
+// New returns the theme selected by the config, with its color overrides
+// applied. The "auto" theme picks the dark or light theme depending on the
+// terminal's background.
+func New(cfg config.ThemeConfig, hasDarkBackground bool) (Theme, error) {
+	name := cfg.Name
+	if name == "" || name == Auto {
+		name = Light
+		if hasDarkBackground {
+			name = Dark
+		}
+	}
+	t, ok := themes[name]
+	if !ok {
+		return themes[Dark], fmt.Errorf("theme: unknown theme %q, expected one of %v", cfg.Name, Names())
+	}
+
+	var errs []error
+	names := make([]string, 0, len(cfg.Colors))
+	for name := range cfg.Colors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		field := t.color(name)
+		if field == nil {
+			errs = append(errs, fmt.Errorf("theme: unknown color %q", name))
+			continue
+		}
+		c, err := parseColor(cfg.Colors[name])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("theme: color %q: %w", name, err))
+			continue
+		}
+		*field = c
+	}
+	return t, errors.Join(errs...)
+}
+
+// color returns the field holding the color with the given config name.
+func (t *Theme) color(name string) *color.Color {
+	switch name {
+	case "selection":
+		return &t.Selection
+	case "bar":
+		return &t.Bar
+	case "cursor":
+		return &t.Cursor
+	case "text":
+		return &t.Text
+	case "muted":
+		return &t.Muted
+	case "accent":
+		return &t.Accent
+	case "directory":
+		return &t.Directory
+	case "info":
+		return &t.Info
+	case "added":
+		return &t.Added
+	case "deleted":
+		return &t.Deleted
+	case "modified":
+		return &t.Modified
+	case "renamed":
+		return &t.Renamed
+	case "copied":
+		return &t.Copied
+	case "binary":
+		return &t.Binary
+	case "modeChanged":
+		return &t.ModeChanged
+	case "marked":
+		return &t.Marked
+	case "churn":
+		return &t.Churn
+	}
+	return nil
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// parseColor accepts hex colors such as "#7aa2f7" and ANSI color numbers
+// from 0 to 255.
+func parseColor(s string) (color.Color, error) {
+	if hexColor.MatchString(s) {
+		return lipgloss.Color(s), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return lipgloss.Color(s), nil
+	}
+	return nil, fmt.Errorf("invalid color %q, expected a hex color or an ANSI color number", s)
+}
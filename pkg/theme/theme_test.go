@@ -0,0 +1,69 @@
+package theme
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/lipgloss/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+)
+
+func TestNewPicksThemeForBackground(t *testing.T) {
+	tests := []struct {
+		name string
+		dark bool
+		want string
+	}{
+		{name: "", dark: true, want: Dark},
+		{name: Auto, dark: false, want: Light},
+		{name: HighContrast, dark: false, want: HighContrast},
+		{name: Light, dark: true, want: Light},
+	}
+	for _, tt := range tests {
+		got, err := New(config.ThemeConfig{Name: tt.name}, tt.dark)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Name != tt.want {
+			t.Errorf("New(%q, dark=%v) = %q, want %q", tt.name, tt.dark, got.Name, tt.want)
+		}
+	}
+}
+
+func TestNewAppliesColorOverrides(t *testing.T) {
+	got, err := New(config.ThemeConfig{
+		Name:   Dark,
+		Colors: map[string]string{"accent": "#7aa2f7", "muted": "245"},
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Accent != lipgloss.Color("#7aa2f7") {
+		t.Errorf("expected accent to be overridden, got %v", got.Accent)
+	}
+	if got.Muted != lipgloss.Color("245") {
+		t.Errorf("expected muted to be overridden, got %v", got.Muted)
+	}
+	if got.Added != themes[Dark].Added {
+		t.Errorf("expected other colors to be kept, got added %v", got.Added)
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	tests := []struct {
+		cfg  config.ThemeConfig
+		want string
+	}{
+		{cfg: config.ThemeConfig{Name: "solarized"}, want: `unknown theme "solarized"`},
+		{cfg: config.ThemeConfig{Colors: map[string]string{"acent": "4"}}, want: `unknown color "acent"`},
+		{cfg: config.ThemeConfig{Colors: map[string]string{"accent": "blue"}}, want: `invalid color "blue"`},
+		{cfg: config.ThemeConfig{Colors: map[string]string{"accent": "256"}}, want: `invalid color "256"`},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.cfg, true)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%+v): expected an error containing %q, got %v", tt.cfg, tt.want, err)
+		}
+	}
+}
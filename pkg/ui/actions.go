@@ -0,0 +1,188 @@
+package ui
+
+import (
+	"math"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/filesort"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/symbols"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// mainAction returns the binding of the action of the main view msg is bound
+// to, or nil.
+func (k *KeyMap) mainAction(msg tea.KeyPressMsg) *key.Binding {
+	for _, a := range k.actions() {
+		if a.scope() == "" && key.Matches(msg, *a.binding) {
+			return a.binding
+		}
+	}
+	return nil
+}
+
+// paneActions returns the bindings of the actions the panes handle as their
+// keys are routed to them.
+func (k *KeyMap) paneActions() []*key.Binding {
+	return []*key.Binding{
+		&k.ExpandNode, &k.CollapseNode, &k.ToggleNode,
+		&k.CtrlD, &k.CtrlU, &k.PageDown, &k.PageUp,
+	}
+}
+
+// runAction runs the action of the main view bound to b. The motions are
+// repeated count times, and gg and G go to the file, or the line of the diff,
+// count is the number of. Other actions ignore count.
+func (m mainModel) runAction(b *key.Binding, count int) (mainModel, tea.Cmd) {
+	var cmd tea.Cmd
+	n := max(count, 1)
+	switch b {
+	case &m.keys.Quit:
+		return m, tea.Quit
+	case &m.keys.ToggleHelp:
+		m.helpOpen = !m.helpOpen
+	case &m.keys.FilterFiles:
+		if m.isShowingFileTree {
+			m.filtering = true
+			m.fileTree.SetSize(m.sidebarWidth(), m.fileTreeHeight())
+		}
+	case &m.keys.CommandPalette:
+		cmd = m.openPalette("")
+	case &m.keys.ExportPatch:
+		cmd = m.openPalette(exportCommand + " ")
+	case &m.keys.CopyPatch:
+		m, cmd = m.copyPatch()
+	case &m.keys.Search:
+		m.searching = true
+		m.search.SetWidth(m.searchWidth())
+		m.search.SetValue("")
+		m.resultsCursor = 0
+		m.setSearchResults()
+
+		m.resultsVp.SetWidth(m.sidebarWidth())
+		m.resultsVp.SetHeight(m.sidebarHeight() - searchHeight)
+		m.resultsVp.SetContent(m.resultsView())
+
+		cmd = tea.Batch(m.diffViewer.SetSize(m.diffWidth(), m.diffHeight()), m.search.Focus())
+	case &m.keys.ToggleFileTree:
+		m.isShowingFileTree = !m.isShowingFileTree
+		if !m.isShowingFileTree {
+			m.activePanel = DiffViewerPanel
+		} else {
+			m.activePanel = FileTreePanel
+		}
+		cmd = m.resize()
+	case &m.keys.ToggleIconStyle:
+		m.cycleIconStyle()
+	case &m.keys.CycleSort:
+		m.sortMode = filesort.Next(m.sortMode)
+		m, cmd = m.resortFiles()
+	case &m.keys.ToggleFlatView:
+		m.fileTree.ToggleMode()
+		if len(m.files) > 0 {
+			m, cmd = m.setNodeDiff(m.fileTree.GetCurrNode())
+		}
+	case &m.keys.ToggleOutline:
+		if m.isShowingFileTree && len(m.files) > 0 {
+			m.outlineOpen = true
+			m.outline.SetSize(m.sidebarWidth(), m.outlineHeight())
+			files := m.fileTree.GetCurrNodeDesendantDiffs()
+			m.outline.Reset(files)
+			cmd = symbols.Load(files, utils.GitTopLevel(), m.outline.Gen())
+		}
+	case &m.keys.GroupByOwner:
+		m.fileTree.ToggleOwnerGroups()
+		if len(m.files) > 0 {
+			m, cmd = m.setNodeDiff(m.fileTree.GetCurrNode())
+		}
+	case &m.keys.ToggleDiffView:
+		// The view modes cycle from auto, when it's configured, to
+		// side-by-side and unified.
+		switch {
+		case m.diffViewer.AutoSideBySide():
+			m.diffViewer.SetAutoSideBySide(0)
+			m.sideBySide = true
+		case !m.sideBySide && m.config.UI.AutoSideBySide:
+			m.diffViewer.SetAutoSideBySide(m.config.UI.AutoSideBySideWidth)
+		default:
+			m.sideBySide = !m.sideBySide
+		}
+		cmd = m.diffViewer.SetSideBySide(m.sideBySide)
+	case &m.keys.CyclePreset:
+		cmd = m.diffViewer.CyclePreset()
+	case &m.keys.SwitchPanel:
+		if m.isShowingFileTree {
+			if m.activePanel == FileTreePanel {
+				m.activePanel = DiffViewerPanel
+			} else {
+				m.activePanel = FileTreePanel
+			}
+		}
+	case &m.keys.JumpBack:
+		m, cmd = m.jump(true)
+	case &m.keys.JumpForward:
+		m, cmd = m.jump(false)
+	case &m.keys.GoToTop:
+		m, cmd = m.goTo(n)
+	case &m.keys.GoToBottom:
+		if count == 0 {
+			count = math.MaxInt
+		}
+		m, cmd = m.goTo(count)
+	case &m.keys.CenterCursor:
+		m.fileTree.CenterCursor()
+	case &m.keys.PrevFile:
+		m, cmd = m.moveToFile(-n)
+	case &m.keys.NextFile:
+		m, cmd = m.moveToFile(n)
+	case &m.keys.Up:
+		if m.activePanel == FileTreePanel {
+			m, cmd = m.moveCursor(-n)
+		} else {
+			m.diffViewer.ScrollUp(n)
+		}
+	case &m.keys.Down:
+		if m.activePanel == FileTreePanel {
+			m, cmd = m.moveCursor(n)
+		} else {
+			m.diffViewer.ScrollDown(n)
+		}
+	case &m.keys.CtrlD:
+		repeat(n, m.diffViewer.HalfPageDown)
+	case &m.keys.CtrlU:
+		repeat(n, m.diffViewer.HalfPageUp)
+	case &m.keys.PageDown:
+		repeat(n, m.diffViewer.PageDown)
+	case &m.keys.PageUp:
+		repeat(n, m.diffViewer.PageUp)
+	case &m.keys.ExpandNode:
+		m.fileTree.ExpandNode()
+	case &m.keys.CollapseNode:
+		m.fileTree.CollapseNode()
+	case &m.keys.ToggleNode:
+		m.fileTree.ToggleNode()
+	case &m.keys.ToggleViewed:
+		m.toggleViewed()
+	case &m.keys.ToggleSelect:
+		m.fileTree.ToggleSelected()
+	case &m.keys.ShowSelection:
+		if files := m.fileTree.SelectedFiles(); len(files) > 0 {
+			m.diffViewer, cmd = m.diffViewer.SetSelectionPatch(files)
+			m.diffViewer.GoToTop()
+		}
+	case &m.keys.ClearSelection:
+		m.fileTree.ClearSelection()
+	case &m.keys.SelectLines:
+		m = m.selectLines()
+	case &m.keys.Copy:
+		if len(m.fileTree.SelectedFiles()) > 0 {
+			cmd = m.fileTree.CopySelectedPaths()
+		} else {
+			cmd = m.fileTree.CopyCurrNodePath()
+		}
+	case &m.keys.OpenInEditor:
+		cmd = m.openInEditor()
+	}
+	return m, cmd
+}
@@ -0,0 +1,96 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+)
+
+// nodePatch returns the patch of the selected files, or else of the files
+// under the cursor, as git diff prints it so that git apply takes it as is.
+// Binary files whose patch has no data can't be applied, so they're left out
+// and their paths returned.
+func (m mainModel) nodePatch() (string, []string, error) {
+	files := m.fileTree.SelectedFiles()
+	if len(files) == 0 {
+		files = m.fileTree.GetCurrNodeDesendantDiffs()
+	}
+	if len(files) == 0 {
+		return "", nil, errors.New("no files under the cursor")
+	}
+	var sb strings.Builder
+	var skipped []string
+	for _, file := range files {
+		if file.IsBinary && file.BinaryFragment == nil {
+			skipped = append(skipped, filenode.GetFileName(file))
+			continue
+		}
+		sb.WriteString(file.String())
+	}
+	if sb.Len() == 0 {
+		return "", skipped, errors.New("no patch to apply: binary files need git diff --binary")
+	}
+	return sb.String(), skipped, nil
+}
+
+// skippedError reports the binary files left out of a patch, or is nil.
+func skippedError(skipped []string) error {
+	if len(skipped) == 0 {
+		return nil
+	}
+	return fmt.Errorf("left out binary files without data (use git diff --binary): %s", strings.Join(skipped, ", "))
+}
+
+// copyPatch copies the patch of the selected files, or else of the files
+// under the cursor.
+func (m mainModel) copyPatch() (mainModel, tea.Cmd) {
+	patch, skipped, err := m.nodePatch()
+	if err != nil {
+		m.statusErr = err
+		return m, nil
+	}
+	m.statusErr = skippedError(skipped)
+	return m, copyText(patch)
+}
+
+// exportPatch writes the patch of the selected files, or else of the files
+// under the cursor, to the given path, which mustn't exist. A leading ~ is
+// the home directory.
+func (m mainModel) exportPatch(path string) (mainModel, error) {
+	if path == "" {
+		return m, errors.New("export needs a path to write the patch to")
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return m, fmt.Errorf("export: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	patch, skipped, err := m.nodePatch()
+	if err != nil {
+		return m, err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, os.ErrExist) {
+		return m, fmt.Errorf("export: %s already exists", path)
+	}
+	if err != nil {
+		return m, fmt.Errorf("export: %w", err)
+	}
+	if _, err := f.WriteString(patch); err != nil {
+		f.Close()
+		return m, fmt.Errorf("export: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return m, fmt.Errorf("export: %w", err)
+	}
+	m.statusErr = skippedError(skipped)
+	return m, nil
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"slices"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/hunknode"
+)
+
+// maxJumps is the number of locations kept in the jump history.
+const maxJumps = 100
+
+// location is a place of the jump history: the node under the tree's cursor,
+// along with the scroll offsets of the tree and of the diff.
+type location struct {
+	// path is the path of the node, and hunk the index of the hunk when the
+	// node is one, or -1.
+	path   string
+	hunk   int
+	cursor int
+	scroll int
+	offset int
+}
+
+func (l location) sameNode(other location) bool {
+	return l.path == other.path && l.hunk == other.hunk
+}
+
+// jumpList holds the locations left by navigating, oldest first, like the
+// jump list of vim. A location is only listed once, where it was left last.
+type jumpList struct {
+	jumps []location
+	// pos is the location being shown while going through the list, or
+	// len(jumps) when not going through it.
+	pos int
+	// gen changes whenever the list does, so that an update going through
+	// nested updates, as the palette's do, records a jump only once.
+	gen int
+}
+
+// push records a location left by navigating away from it.
+func (l *jumpList) push(loc location) {
+	l.jumps = slices.DeleteFunc(l.jumps, loc.sameNode)
+	l.jumps = append(l.jumps, loc)
+	if len(l.jumps) > maxJumps {
+		l.jumps = l.jumps[len(l.jumps)-maxJumps:]
+	}
+	l.pos = len(l.jumps)
+	l.gen++
+}
+
+// back returns the location before the current one, which is recorded to
+// come back to with forward.
+func (l *jumpList) back(curr location) (location, bool) {
+	if l.pos >= len(l.jumps) {
+		if len(l.jumps) == 0 {
+			return location{}, false
+		}
+		l.push(curr)
+		l.pos = len(l.jumps) - 1
+	} else {
+		l.jumps[l.pos] = curr
+	}
+	if l.pos == 0 {
+		return location{}, false
+	}
+	l.pos--
+	l.gen++
+	return l.jumps[l.pos], true
+}
+
+// forward returns the location after the current one, once back was used.
+func (l *jumpList) forward(curr location) (location, bool) {
+	if l.pos >= len(l.jumps)-1 {
+		return location{}, false
+	}
+	l.jumps[l.pos] = curr
+	l.pos++
+	l.gen++
+	return l.jumps[l.pos], true
+}
+
+// location returns where the cursor is, or false if there are no files.
+func (m mainModel) location() (location, bool) {
+	node := m.fileTree.GetCurrNode()
+	if len(m.files) == 0 || node == nil {
+		return location{}, false
+	}
+	loc := location{path: m.fileTree.CurrNodePath(), hunk: -1, offset: m.diffViewer.YOffset()}
+	if hunk, ok := node.GivenValue().(*hunknode.HunkNode); ok {
+		loc.hunk = hunk.Index
+	}
+	loc.cursor, loc.scroll = m.fileTree.Cursor()
+	return loc, true
+}
+
+// recordJump records the location an update started from when the update
+// moved the cursor to another node, unless it went through the history.
+func (m *mainModel) recordJump(from location, ok bool, gen int) {
+	if !ok || m.jumps.gen != gen {
+		return
+	}
+	if to, ok := m.location(); ok && !to.sameNode(from) {
+		m.jumps.push(from)
+	}
+}
+
+// jump goes back through the jump history, or forward when back is false.
+func (m mainModel) jump(back bool) (mainModel, tea.Cmd) {
+	curr, ok := m.location()
+	if !ok {
+		return m, nil
+	}
+	var loc location
+	if back {
+		loc, ok = m.jumps.back(curr)
+	} else {
+		loc, ok = m.jumps.forward(curr)
+	}
+	if !ok {
+		return m, nil
+	}
+
+	// The tree may have changed since, in which case the node is looked up
+	// by its path.
+	m.fileTree.SetCursor(loc.cursor, loc.scroll)
+	if at, _ := m.location(); !at.sameNode(loc) {
+		m.fileTree.SetCursorByPath(loc.path)
+	}
+	var cmd tea.Cmd
+	m, cmd = m.setNodeDiff(m.fileTree.GetCurrNode())
+	m.diffViewer.SetYOffset(loc.offset)
+	return m, cmd
+}
@@ -1,6 +1,18 @@
 package ui
 
-import "charm.land/bubbles/v2/key"
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/diffviewer"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/filetree"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/palette"
+)
 
 type KeyMap struct {
 	ExpandNode      key.Binding
@@ -12,6 +24,15 @@ type KeyMap struct {
 	PrevFile        key.Binding
 	CtrlD           key.Binding
 	CtrlU           key.Binding
+	PageDown        key.Binding
+	PageUp          key.Binding
+	GoToTop         key.Binding
+	GoToBottom      key.Binding
+	CenterCursor    key.Binding
+	JumpBack        key.Binding
+	JumpForward     key.Binding
+	Count           key.Binding
+	CommandPalette  key.Binding
 	ToggleFileTree  key.Binding
 	Search          key.Binding
 	Quit            key.Binding
@@ -19,103 +40,667 @@ type KeyMap struct {
 	SwitchPanel     key.Binding
 	OpenInEditor    key.Binding
 	ToggleDiffView  key.Binding
+	CyclePreset     key.Binding
 	ToggleIconStyle key.Binding
+	CycleSort       key.Binding
+	ToggleFlatView  key.Binding
+	FilterFiles     key.Binding
+	ToggleViewed    key.Binding
+	GroupByOwner    key.Binding
+	ToggleOutline   key.Binding
+	ToggleSelect    key.Binding
+	ShowSelection   key.Binding
+	ClearSelection  key.Binding
+	SelectLines     key.Binding
+	CopyPatch       key.Binding
+	ExportPatch     key.Binding
 	ToggleHelp      key.Binding
+
+	Filter    FilterKeyMap
+	Outline   OutlineKeyMap
+	Visual    VisualKeyMap
+	SearchBox SearchKeyMap
+	Palette   PaletteKeyMap
+}
+
+// NewKeyMap returns the default key bindings with the overrides from the
+// config's keys section applied. Unknown actions and keys bound to more than
+// one action of the same scope are reported as errors, along with the key
+// map.
+func NewKeyMap(overrides config.KeysConfig) (*KeyMap, error) {
+	k := defaultKeyMap()
+	actions := k.actions()
+
+	var errs []error
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		a := findAction(actions, name)
+		if a == nil {
+			errs = append(errs, fmt.Errorf("keys: unknown action %q", name))
+			continue
+		}
+		rebind(a.binding, overrides[name])
+	}
+	// gg and zz are pressed twice, see motionUpdate.
+	for _, b := range []*key.Binding{&k.GoToTop, &k.CenterCursor} {
+		b.SetHelp(pressedTwice(b.Keys()), b.Help().Desc)
+	}
+
+	return &k, errors.Join(append(errs, conflicts(actions)...)...)
+}
+
+// action is a rebindable action of the keys config section.
+type action struct {
+	// Name is the key of the action in the keys section, prefixed by its
+	// scope when the action is only available in a mode, e.g. "filter.added".
+	Name    string
+	binding *key.Binding
+}
+
+// scope returns the mode the action is available in, or "" for the main
+// view. Keys only conflict within a scope.
+func (a action) scope() string {
+	scope, _, found := strings.Cut(a.Name, ".")
+	if !found {
+		return ""
+	}
+	return scope
+}
+
+func (k *KeyMap) actions() []action {
+	return []action{
+		{"up", &k.Up},
+		{"down", &k.Down},
+		{"expandNode", &k.ExpandNode},
+		{"collapseNode", &k.CollapseNode},
+		{"toggleNode", &k.ToggleNode},
+		{"nextFile", &k.NextFile},
+		{"prevFile", &k.PrevFile},
+		{"scrollDown", &k.CtrlD},
+		{"scrollUp", &k.CtrlU},
+		{"pageDown", &k.PageDown},
+		{"pageUp", &k.PageUp},
+		{"goToTop", &k.GoToTop},
+		{"goToBottom", &k.GoToBottom},
+		{"centerCursor", &k.CenterCursor},
+		{"jumpBack", &k.JumpBack},
+		{"jumpForward", &k.JumpForward},
+		{"commandPalette", &k.CommandPalette},
+		{"toggleFileTree", &k.ToggleFileTree},
+		{"search", &k.Search},
+		{"quit", &k.Quit},
+		{"copy", &k.Copy},
+		{"switchPanel", &k.SwitchPanel},
+		{"openInEditor", &k.OpenInEditor},
+		{"toggleDiffView", &k.ToggleDiffView},
+		{"cyclePreset", &k.CyclePreset},
+		{"toggleIconStyle", &k.ToggleIconStyle},
+		{"cycleSort", &k.CycleSort},
+		{"toggleFlatView", &k.ToggleFlatView},
+		{"filterFiles", &k.FilterFiles},
+		{"toggleViewed", &k.ToggleViewed},
+		{"groupByOwner", &k.GroupByOwner},
+		{"toggleOutline", &k.ToggleOutline},
+		{"toggleSelect", &k.ToggleSelect},
+		{"showSelection", &k.ShowSelection},
+		{"clearSelection", &k.ClearSelection},
+		{"selectLines", &k.SelectLines},
+		{"copyPatch", &k.CopyPatch},
+		{"exportPatch", &k.ExportPatch},
+		{"toggleHelp", &k.ToggleHelp},
+		{"filter.added", &k.Filter.Added},
+		{"filter.modified", &k.Filter.Modified},
+		{"filter.deleted", &k.Filter.Deleted},
+		{"filter.renamed", &k.Filter.Renamed},
+		{"filter.binary", &k.Filter.Binary},
+		{"filter.close", &k.Filter.Close},
+		{"outline.up", &k.Outline.Up},
+		{"outline.down", &k.Outline.Down},
+		{"outline.select", &k.Outline.Select},
+		{"outline.close", &k.Outline.Close},
+		{"outline.quit", &k.Outline.Quit},
+		{"visual.up", &k.Visual.Up},
+		{"visual.down", &k.Visual.Down},
+		{"visual.copyNew", &k.Visual.CopyNew},
+		{"visual.copyOld", &k.Visual.CopyOld},
+		{"visual.copyPatch", &k.Visual.CopyPatch},
+		{"visual.close", &k.Visual.Close},
+		{"visual.quit", &k.Visual.Quit},
+		{"search.up", &k.SearchBox.Up},
+		{"search.down", &k.SearchBox.Down},
+		{"search.select", &k.SearchBox.Select},
+		{"search.close", &k.SearchBox.Close},
+		{"search.quit", &k.SearchBox.Quit},
+		{"palette.up", &k.Palette.Up},
+		{"palette.down", &k.Palette.Down},
+		{"palette.complete", &k.Palette.Complete},
+		{"palette.run", &k.Palette.Run},
+		{"palette.close", &k.Palette.Close},
+		{"palette.quit", &k.Palette.Quit},
+	}
+}
+
+func findAction(actions []action, name string) *action {
+	for i := range actions {
+		if actions[i].Name == name {
+			return &actions[i]
+		}
+	}
+	return nil
+}
+
+// rebind replaces the keys of a binding and updates its help to match. No
+// keys disables the binding.
+func rebind(b *key.Binding, keys []string) {
+	if len(keys) == 0 {
+		b.SetEnabled(false)
+		return
+	}
+	b.SetKeys(keys...)
+	b.SetHelp(strings.Join(keys, "/"), b.Help().Desc)
+	b.SetEnabled(true)
+}
+
+// pressedTwice returns the help of keys that are pressed twice, e.g. "gg".
+func pressedTwice(keys []string) string {
+	twice := make([]string, len(keys))
+	for i, k := range keys {
+		twice[i] = k + k
+	}
+	return strings.Join(twice, "/")
+}
+
+// legacyKeys maps keys to the ones terminals without keyboard disambiguation
+// send for them, e.g. tab for ctrl+i.
+var legacyKeys = map[string]string{
+	"ctrl+i": "tab",
+	"ctrl+m": "enter",
+	"ctrl+[": "esc",
+	"ctrl+h": "backspace",
+}
+
+// conflicts reports keys bound to several enabled actions of the same scope,
+// and actions left without a key in terminals sending their keys as others
+// bound in the scope.
+func conflicts(actions []action) []error {
+	var errs []error
+	bound := map[string]string{}
+	for _, a := range actions {
+		if !a.binding.Enabled() {
+			continue
+		}
+		for _, k := range a.binding.Keys() {
+			id := a.scope() + "\x00" + k
+			if other, ok := bound[id]; ok {
+				errs = append(errs, fmt.Errorf("keys: %q is bound to both %q and %q", k, other, a.Name))
+				continue
+			}
+			bound[id] = a.Name
+		}
+	}
+
+	// An action is only left without a key when all of its keys are sent as
+	// keys of other actions.
+	for _, a := range actions {
+		if !a.binding.Enabled() {
+			continue
+		}
+		var shadowed []error
+		for _, k := range a.binding.Keys() {
+			legacy, ok := legacyKeys[k]
+			if !ok {
+				break
+			}
+			other, ok := bound[a.scope()+"\x00"+legacy]
+			if !ok || other == a.Name {
+				break
+			}
+			shadowed = append(shadowed, fmt.Errorf(
+				"keys: %q of %q is sent as %q, bound to %q, by terminals without keyboard disambiguation; add another key",
+				k, a.Name, legacy, other))
+		}
+		if len(shadowed) == len(a.binding.Keys()) {
+			errs = append(errs, shadowed...)
+		}
+	}
+	return errs
+}
+
+// fileTreeKeys returns the bindings the file tree handles itself.
+func (k *KeyMap) fileTreeKeys() filetree.KeyMap {
+	return filetree.KeyMap{
+		ExpandNode:   k.ExpandNode,
+		CollapseNode: k.CollapseNode,
+		ToggleNode:   k.ToggleNode,
+	}
+}
+
+// diffViewerKeys returns the bindings the diff viewer needs to know about.
+func (k *KeyMap) diffViewerKeys() diffviewer.KeyMap {
+	return diffviewer.KeyMap{
+		HalfPageDown: k.CtrlD,
+		HalfPageUp:   k.CtrlU,
+		PageDown:     k.PageDown,
+		PageUp:       k.PageUp,
+	}
+}
+
+// paletteKeys returns the bindings the command palette handles itself.
+func (k *KeyMap) paletteKeys() palette.KeyMap {
+	return palette.KeyMap{
+		Up:       k.Palette.Up,
+		Down:     k.Palette.Down,
+		Complete: k.Palette.Complete,
+	}
+}
+
+func defaultKeyMap() KeyMap {
+	return KeyMap{
+		ExpandNode: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "expand"),
+		),
+		CollapseNode: key.NewBinding(
+			key.WithKeys("h"),
+			key.WithHelp("h", "collapse"),
+		),
+		ToggleNode: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "toggle"),
+		),
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "prev file"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "next file"),
+		),
+		NextFile: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next file"),
+		),
+		PrevFile: key.NewBinding(
+			key.WithKeys("p", "N"),
+			key.WithHelp("p/N", "prev file"),
+		),
+		CtrlD: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "diff down"),
+		),
+		CtrlU: key.NewBinding(
+			key.WithKeys("ctrl+u"),
+			key.WithHelp("ctrl+u", "diff up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("ctrl+f"),
+			key.WithHelp("ctrl+f", "diff page down"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("ctrl+b"),
+			key.WithHelp("ctrl+b", "diff page up"),
+		),
+		GoToTop: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "first file / top"),
+		),
+		GoToBottom: key.NewBinding(
+			key.WithKeys("G"),
+			key.WithHelp("G", "last file / bottom"),
+		),
+		CenterCursor: key.NewBinding(
+			key.WithKeys("z"),
+			key.WithHelp("z", "center cursor"),
+		),
+		JumpBack: key.NewBinding(
+			key.WithKeys("ctrl+o"),
+			key.WithHelp("ctrl+o", "jump back"),
+		),
+		JumpForward: key.NewBinding(
+			key.WithKeys("ctrl+i", "alt+i"),
+			key.WithHelp("ctrl+i/alt+i", "jump forward"),
+		),
+		CommandPalette: key.NewBinding(
+			key.WithKeys(":", "ctrl+p"),
+			key.WithHelp(":/ctrl+p", "command palette"),
+		),
+		Count: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "count prefix"),
+		),
+		ToggleFileTree: key.NewBinding(
+			key.WithKeys("e"),
+			key.WithHelp("e", "toggle file tree"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "search files"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("q", "ctrl+c"),
+			key.WithHelp("q", "quit"),
+		),
+		Copy: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy file path"),
+		),
+		SwitchPanel: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "switch panel"),
+		),
+		OpenInEditor: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open"),
+		),
+		ToggleDiffView: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "toggle side-by-side"),
+		),
+		CyclePreset: key.NewBinding(
+			key.WithKeys("P"),
+			key.WithHelp("P", "cycle delta preset"),
+		),
+		ToggleIconStyle: key.NewBinding(
+			key.WithKeys("i"),
+			key.WithHelp("i", "toggle icon style"),
+		),
+		CycleSort: key.NewBinding(
+			key.WithKeys("S"),
+			key.WithHelp("S", "cycle sort mode"),
+		),
+		ToggleFlatView: key.NewBinding(
+			key.WithKeys("F"),
+			key.WithHelp("F", "toggle flat list"),
+		),
+		FilterFiles: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "filter by status"),
+		),
+		ToggleViewed: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "toggle viewed"),
+		),
+		GroupByOwner: key.NewBinding(
+			key.WithKeys("O"),
+			key.WithHelp("O", "group by owner"),
+		),
+		ToggleOutline: key.NewBinding(
+			key.WithKeys("T"),
+			key.WithHelp("T", "symbol outline"),
+		),
+		ToggleSelect: key.NewBinding(
+			key.WithKeys("space"),
+			key.WithHelp("space", "select file"),
+		),
+		ShowSelection: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "diff selected files"),
+		),
+		ClearSelection: key.NewBinding(
+			key.WithKeys("C"),
+			key.WithHelp("C", "clear selection"),
+		),
+		SelectLines: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "select diff lines"),
+		),
+		CopyPatch: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy patch of selection/node"),
+		),
+		ExportPatch: key.NewBinding(
+			key.WithKeys("W"),
+			key.WithHelp("W", "write patch of selection/node"),
+		),
+		ToggleHelp: key.NewBinding(
+			key.WithKeys("?", "f1"),
+			key.WithHelp("F1/?", "toggle help"),
+		),
+		Filter:    defaultFilterKeyMap(),
+		Outline:   defaultOutlineKeyMap(),
+		Visual:    defaultVisualKeyMap(),
+		SearchBox: defaultSearchKeyMap(),
+		Palette:   defaultPaletteKeyMap(),
+	}
+}
+
+// FilterKeyMap holds the keys active while the status filter bar is focused.
+type FilterKeyMap struct {
+	Added    key.Binding
+	Modified key.Binding
+	Deleted  key.Binding
+	Renamed  key.Binding
+	Binary   key.Binding
+	Close    key.Binding
+}
+
+func defaultFilterKeyMap() FilterKeyMap {
+	return FilterKeyMap{
+		Added: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "toggle added"),
+		),
+		Modified: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "toggle modified"),
+		),
+		Deleted: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "toggle deleted"),
+		),
+		Renamed: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "toggle renamed"),
+		),
+		Binary: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "toggle binary"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "enter", "f"),
+			key.WithHelp("esc", "close filter"),
+		),
+	}
+}
+
+// OutlineKeyMap holds the keys active while the symbol outline is open.
+type OutlineKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Close  key.Binding
+	Quit   key.Binding
+}
+
+func defaultOutlineKeyMap() OutlineKeyMap {
+	return OutlineKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "prev symbol"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "next symbol"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "go to symbol"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "T", "q"),
+			key.WithHelp("esc", "close outline"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
+	}
+}
+
+// VisualKeyMap holds the keys active while lines of the diff are selected.
+type VisualKeyMap struct {
+	Up        key.Binding
+	Down      key.Binding
+	CopyNew   key.Binding
+	CopyOld   key.Binding
+	CopyPatch key.Binding
+	Close     key.Binding
+	Quit      key.Binding
+}
+
+func defaultVisualKeyMap() VisualKeyMap {
+	return VisualKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "extend up"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "extend down"),
+		),
+		CopyNew: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy new text"),
+		),
+		CopyOld: key.NewBinding(
+			key.WithKeys("Y"),
+			key.WithHelp("Y", "copy old text"),
+		),
+		CopyPatch: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "copy as patch"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc", "v"),
+			key.WithHelp("esc", "stop selecting"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
+	}
+}
+
+// SearchKeyMap holds the keys active while searching for a file. Other keys
+// are typed in the search box.
+type SearchKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Close  key.Binding
+	Quit   key.Binding
+}
+
+func defaultSearchKeyMap() SearchKeyMap {
+	return SearchKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "prev result"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next result"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "go to file"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "close search"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
+	}
+}
+
+// PaletteKeyMap holds the keys active while the command palette is open.
+// Other keys are typed in the palette.
+type PaletteKeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Complete key.Binding
+	Run      key.Binding
+	Close    key.Binding
+	Quit     key.Binding
+}
+
+func defaultPaletteKeyMap() PaletteKeyMap {
+	return PaletteKeyMap{
+		Up: key.NewBinding(
+			key.WithKeys("up", "ctrl+p"),
+			key.WithHelp("↑/ctrl+p", "prev command"),
+		),
+		Down: key.NewBinding(
+			key.WithKeys("down", "ctrl+n"),
+			key.WithHelp("↓/ctrl+n", "next command"),
+		),
+		Complete: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "complete"),
+		),
+		Run: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "run command"),
+		),
+		Close: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "close palette"),
+		),
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "quit"),
+		),
+	}
 }
 
-var keys = &KeyMap{
-	ExpandNode: key.NewBinding(
-		key.WithKeys("l"),
-		key.WithHelp("l", "expand"),
-	),
-	CollapseNode: key.NewBinding(
-		key.WithKeys("h"),
-		key.WithHelp("h", "collapse"),
-	),
-	ToggleNode: key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "toggle"),
-	),
-	Up: key.NewBinding(
-		key.WithKeys("up", "k"),
-		key.WithHelp("↑/k", "prev file"),
-	),
-	Down: key.NewBinding(
-		key.WithKeys("down", "j"),
-		key.WithHelp("↓/j", "next file"),
-	),
-	NextFile: key.NewBinding(
-		key.WithKeys("n"),
-		key.WithHelp("n", "next file"),
-	),
-	PrevFile: key.NewBinding(
-		key.WithKeys("p", "N"),
-		key.WithHelp("p/N", "prev file"),
-	),
-	CtrlD: key.NewBinding(
-		key.WithKeys("ctrl+d"),
-		key.WithHelp("ctrl+d", "diff down"),
-	),
-	CtrlU: key.NewBinding(
-		key.WithKeys("ctrl+u"),
-		key.WithHelp("ctrl+u", "diff up"),
-	),
-	ToggleFileTree: key.NewBinding(
-		key.WithKeys("e"),
-		key.WithHelp("e", "toggle file tree"),
-	),
-	Search: key.NewBinding(
-		key.WithKeys("t"),
-		key.WithHelp("t", "search files"),
-	),
-	Quit: key.NewBinding(
-		key.WithKeys("q", "ctrl+c"),
-		key.WithHelp("q", "quit"),
-	),
-	Copy: key.NewBinding(
-		key.WithKeys("y"),
-		key.WithHelp("y", "copy file path"),
-	),
-	SwitchPanel: key.NewBinding(
-		key.WithKeys("tab"),
-		key.WithHelp("tab", "switch panel"),
-	),
-	OpenInEditor: key.NewBinding(
-		key.WithKeys("o"),
-		key.WithHelp("o", "open"),
-	),
-	ToggleDiffView: key.NewBinding(
-		key.WithKeys("s"),
-		key.WithHelp("s", "toggle side-by-side"),
-	),
-	ToggleIconStyle: key.NewBinding(
-		key.WithKeys("i"),
-		key.WithHelp("i", "toggle icon style"),
-	),
-	ToggleHelp: key.NewBinding(
-		key.WithKeys("?", "f1"),
-		key.WithHelp("F1/?", "toggle help"),
-	),
-}
-
-func KeyGroups() [][]key.Binding {
+// Groups returns the bindings shown in the help overlay.
+func (k *KeyMap) Groups() [][]key.Binding {
 	return [][]key.Binding{{
-		keys.SwitchPanel,
-		keys.Up,
-		keys.Down,
-		keys.NextFile,
-		keys.PrevFile,
-		keys.CtrlD,
-		keys.CtrlU,
+		k.SwitchPanel,
+		k.Up,
+		k.Down,
+		k.NextFile,
+		k.PrevFile,
+		k.CtrlD,
+		k.CtrlU,
+		k.PageDown,
+		k.PageUp,
+		k.GoToTop,
+		k.GoToBottom,
+		k.CenterCursor,
+		k.JumpBack,
+		k.JumpForward,
+		k.Count,
+		k.ToggleViewed,
+		k.ToggleSelect,
+		k.ShowSelection,
+		k.ClearSelection,
+		k.SelectLines,
 	}, {
-		keys.ToggleFileTree,
-		keys.Search,
-		keys.Copy,
-		keys.OpenInEditor,
-		keys.ToggleDiffView,
-		keys.ToggleIconStyle,
+		k.ToggleFileTree,
+		k.Search,
+		k.CommandPalette,
+		k.Copy,
+		k.CopyPatch,
+		k.ExportPatch,
+		k.OpenInEditor,
+		k.ToggleDiffView,
+		k.CyclePreset,
+		k.ToggleIconStyle,
+		k.CycleSort,
+		k.ToggleFlatView,
+		k.FilterFiles,
+		k.GroupByOwner,
+		k.ToggleOutline,
 	}, {
-		keys.ToggleHelp,
-		keys.Quit,
+		k.ToggleHelp,
+		k.Quit,
 	}}
 }
@@ -0,0 +1,178 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+)
+
+func TestDefaultKeyMapHasNoConflicts(t *testing.T) {
+	if _, err := NewKeyMap(nil); err != nil {
+		t.Fatalf("expected the default bindings to be valid, got %v", err)
+	}
+}
+
+func TestNewKeyMapOverrides(t *testing.T) {
+	k, err := NewKeyMap(config.KeysConfig{
+		"nextFile":     {"J"},
+		"prevFile":     {"K"},
+		"toggleViewed": {},
+		"filter.added": {"+"},
+		"goToTop":      {"x", "home"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	press := func(s string) tea.KeyPressMsg {
+		return tea.KeyPressMsg(tea.Key{Text: s, Code: rune(s[0])})
+	}
+	if !key.Matches(press("J"), k.NextFile) || key.Matches(press("n"), k.NextFile) {
+		t.Fatalf("expected nextFile to be rebound to J, got %v", k.NextFile.Keys())
+	}
+	if got := k.NextFile.Help(); got.Key != "J" || got.Desc != "next file" {
+		t.Fatalf("expected the help to show the new key, got %+v", got)
+	}
+	if k.ToggleViewed.Enabled() {
+		t.Fatal("expected an empty list to unbind the action")
+	}
+	if !key.Matches(press("+"), k.Filter.Added) {
+		t.Fatalf("expected filter.added to be rebound to +, got %v", k.Filter.Added.Keys())
+	}
+	if got := k.GoToTop.Help().Key; got != "xx/homehome" {
+		t.Fatalf("expected the help to show the keys pressed twice, got %q", got)
+	}
+	if got := k.CenterCursor.Help().Key; got != "zz" {
+		t.Fatalf("expected the help of the default key pressed twice, got %q", got)
+	}
+}
+
+func TestNewKeyMapErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides config.KeysConfig
+		want      string
+	}{
+		{
+			name:      "unknown action",
+			overrides: config.KeysConfig{"nextFiel": {"J"}},
+			want:      `unknown action "nextFiel"`,
+		},
+		{
+			name:      "conflict",
+			overrides: config.KeysConfig{"toggleViewed": {"n"}},
+			want:      `"n" is bound to both "nextFile" and "toggleViewed"`,
+		},
+		{
+			name:      "key sent as another",
+			overrides: config.KeysConfig{"jumpForward": {"ctrl+i"}},
+			want:      `"ctrl+i" of "jumpForward" is sent as "tab", bound to "switchPanel"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyMap(tt.overrides)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("expected an error containing %q, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestNewKeyMapScopesConflicts(t *testing.T) {
+	// The filter keys are only active while the filter bar is open, so they
+	// may reuse keys of the main view.
+	if _, err := NewKeyMap(config.KeysConfig{"filter.added": {"n"}}); err != nil {
+		t.Fatalf("expected keys of different scopes not to conflict, got %v", err)
+	}
+}
+
+func TestReboundKeyNavigatesFiles(t *testing.T) {
+	m := newTestMainModel(t)
+	k, err := NewKeyMap(config.KeysConfig{"nextFile": {"J"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.keys = k
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	before := m.fileTree.CurrNodePath()
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "J", Code: 'J'}))
+	if m.fileTree.CurrNodePath() == before {
+		t.Fatalf("expected J to move to the next file, still on %q", before)
+	}
+}
+
+func TestJumpForwardWithoutKeyboardDisambiguation(t *testing.T) {
+	k, err := NewKeyMap(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Such terminals send tab for ctrl+i, which switches panes.
+	tab := tea.KeyPressMsg(tea.Key{Code: tea.KeyTab})
+	if !key.Matches(tab, k.SwitchPanel) || key.Matches(tab, k.JumpForward) {
+		t.Fatal("expected tab to switch panes")
+	}
+	if !key.Matches(tea.KeyPressMsg(tea.Key{Code: 'i', Mod: tea.ModAlt}), k.JumpForward) {
+		t.Fatalf("expected jumpForward to have a key working everywhere, got %v", k.JumpForward.Keys())
+	}
+}
+
+func TestReboundModeKeys(t *testing.T) {
+	m := newTestMainModel(t)
+	k, err := NewKeyMap(config.KeysConfig{
+		"search.close":  {"ctrl+g"},
+		"palette.close": {"ctrl+g"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.keys = k
+	m.palette.SetKeyMap(k.paletteKeys())
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	ctrlG := tea.KeyPressMsg(tea.Key{Code: 'g', Mod: tea.ModCtrl})
+	esc := tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape})
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "t", Code: 't'}))
+	m = updateMainModel(t, m, esc)
+	if !m.searching {
+		t.Fatal("expected esc not to close the search once unbound")
+	}
+	m = updateMainModel(t, m, ctrlG)
+	if m.searching {
+		t.Fatal("expected ctrl+g to close the search")
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: ":", Code: ':'}))
+	m = updateMainModel(t, m, esc)
+	if !m.paletteOpen {
+		t.Fatal("expected esc not to close the palette once unbound")
+	}
+	m = updateMainModel(t, m, ctrlG)
+	if m.paletteOpen {
+		t.Fatal("expected ctrl+g to close the palette")
+	}
+}
+
+func TestDigitBoundToActionIsNotCount(t *testing.T) {
+	m := newTestMainModel(t)
+	k, err := NewKeyMap(config.KeysConfig{"toggleViewed": {"1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.keys = k
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "1", Code: '1'}))
+	if m.count != 0 || m.viewedCount() == 0 {
+		t.Fatalf("expected 1 to run toggleViewed, got a count of %d", m.count)
+	}
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "2", Code: '2'}))
+	if m.count != 2 {
+		t.Fatalf("expected other digits to start a count, got %d", m.count)
+	}
+}
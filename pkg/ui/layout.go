@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"image/color"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+)
+
+// Layouts of the sidebar, holding the search box and the file tree, and the
+// diff.
+const (
+	// LayoutAuto is LayoutLeft, or LayoutTop in terminals narrower than
+	// ui.autoLayoutWidth.
+	LayoutAuto  = "auto"
+	LayoutLeft  = "left"
+	LayoutRight = "right"
+	// LayoutTop stacks the sidebar above the diff.
+	LayoutTop = "top"
+)
+
+// Layouts lists the accepted values of ui.layout.
+var Layouts = []string{LayoutAuto, LayoutLeft, LayoutRight, LayoutTop}
+
+const (
+	// stackedMinHeight is the smallest height of the stacked sidebar, below
+	// which dragging its border hides it.
+	stackedMinHeight = searchHeight + 3
+	// stackedMinDiffHeight is the smallest height left to the diff when
+	// dragging the border of the stacked sidebar.
+	stackedMinDiffHeight = 5
+)
+
+// layout returns the layout in use, resolving LayoutAuto.
+func (m mainModel) layout() string {
+	switch m.config.UI.Layout {
+	case LayoutRight, LayoutTop:
+		return m.config.UI.Layout
+	case LayoutAuto:
+		if m.width > 0 && m.width < m.config.UI.AutoLayoutWidth {
+			return LayoutTop
+		}
+	}
+	return LayoutLeft
+}
+
+func (m mainModel) isStacked() bool {
+	return m.layout() == LayoutTop
+}
+
+// sidebarHeight returns the height of the sidebar, including the search box.
+func (m mainModel) sidebarHeight() int {
+	if !m.isStacked() {
+		return m.mainContentHeight()
+	}
+	if !m.isSidebarVisible() {
+		return 0
+	}
+	return max(0, min(m.treeHeight, m.mainContentHeight()-stackedMinDiffHeight))
+}
+
+// contentTop returns the row the sidebar and the diff start at, below the
+// header and the separator line.
+func (m mainModel) contentTop() int {
+	return max(m.headerHeight(), 1)
+}
+
+// diffWidth returns the width of the diff viewer.
+func (m mainModel) diffWidth() int {
+	switch m.layout() {
+	case LayoutTop:
+		return m.width
+	case LayoutRight:
+		// The border on the left of the sidebar is drawn even when it's hidden.
+		return max(0, m.width-m.sidebarWidth()-1)
+	default:
+		return m.width - m.sidebarWidth()
+	}
+}
+
+// diffHeight returns the height of the diff viewer. When stacked, the
+// sidebar and its bottom border are above it.
+func (m mainModel) diffHeight() int {
+	if m.isStacked() && m.isSidebarVisible() {
+		return max(0, m.mainContentHeight()-m.sidebarHeight()-1)
+	}
+	return m.mainContentHeight()
+}
+
+// onSidebarBorder reports whether x, y is close enough to the border between
+// the sidebar and the diff to grab it. When the sidebar is hidden, its border
+// is at the edge of the screen it's shown on.
+func (m mainModel) onSidebarBorder(x, y int) bool {
+	switch m.layout() {
+	case LayoutTop:
+		border := m.contentTop() + m.sidebarHeight()
+		if !m.isSidebarVisible() {
+			border = m.contentTop() - 1
+		}
+		return abs(y-border) <= 1
+	case LayoutRight:
+		return abs(x-(m.width-m.sidebarWidth()-1)) <= sidebarGrabThreshold
+	default:
+		return abs(x-m.sidebarWidth()) <= sidebarGrabThreshold
+	}
+}
+
+// mainContentView joins the sidebar and the diff according to the layout.
+// The border between them takes the color of the sidebar's pane.
+func (m mainModel) mainContentView(sidebar, diff string, sidebarColor color.Color) string {
+	switch m.layout() {
+	case LayoutTop:
+		if !m.isSidebarVisible() {
+			return diff
+		}
+		sidebar = lipgloss.NewStyle().
+			Height(m.sidebarHeight()).
+			MaxHeight(m.sidebarHeight()).
+			Render(sidebar)
+		border := lipgloss.NewStyle().
+			Foreground(sidebarColor).
+			Render(strings.Repeat("─", m.width))
+		return lipgloss.JoinVertical(lipgloss.Left, sidebar, border, diff)
+	case LayoutRight:
+		return lipgloss.JoinHorizontal(lipgloss.Top, diff, m.sideBorder(sidebar, sidebarColor, true))
+	default:
+		return lipgloss.JoinHorizontal(lipgloss.Top, m.sideBorder(sidebar, sidebarColor, false), diff)
+	}
+}
+
+// sideBorder draws the border on the diff's side of the sidebar. When the
+// sidebar is hidden, the border alone is left as a grab line.
+func (m mainModel) sideBorder(sidebar string, c color.Color, left bool) string {
+	style := lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder(), false, !left, false, left).
+		BorderForeground(c)
+	if !m.isSidebarVisible() {
+		// Width(0) means only the border is rendered (1 char).
+		return style.Width(0).Height(m.mainContentHeight() - 1).Render("")
+	}
+	return style.Render(sidebar)
+}
+
+// separatorView renders the line below the header, with a junction above the
+// border between the sidebar and the diff.
+func (m mainModel) separatorView(sidebarColor, diffColor color.Color) string {
+	if m.width <= 0 {
+		return ""
+	}
+	line := func(c color.Color, w int) string {
+		return lipgloss.NewStyle().Foreground(c).Render(strings.Repeat("─", max(w, 0)))
+	}
+	if !m.isSidebarVisible() {
+		return line(diffColor, m.width)
+	}
+
+	sidebarW := m.sidebarWidth()
+	switch m.layout() {
+	case LayoutTop:
+		return line(sidebarColor, m.width)
+	case LayoutRight:
+		junction := lipgloss.NewStyle().Foreground(sidebarColor).Render("┬")
+		return line(diffColor, m.width-sidebarW-1) + junction + line(sidebarColor, sidebarW)
+	default:
+		junction := lipgloss.NewStyle().Foreground(sidebarColor).Render("┬")
+		return line(sidebarColor, sidebarW) + junction + line(diffColor, m.width-sidebarW)
+	}
+}
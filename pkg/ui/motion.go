@@ -0,0 +1,104 @@
+package ui
+
+import (
+	"strconv"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+)
+
+// maxCount caps the count typed before a motion.
+const maxCount = 9999
+
+// motionUpdate handles the vim-style motions: the count typed before a
+// motion, the two-key gg and zz, and G. A count repeats the j/k, n/p and
+// scroll motions, and makes gg and G go to the file, or the line of the
+// diff, it's the number of. It reports whether msg was handled, and drops
+// the count and the pending key when it wasn't.
+func (m mainModel) motionUpdate(msg tea.KeyPressMsg) (mainModel, tea.Cmd, bool) {
+	var cmd tea.Cmd
+	count, pending := m.count, m.pending
+	m.count, m.pending = 0, nil
+
+	if pending != nil {
+		if key.Matches(msg, *pending) {
+			m, cmd = m.runAction(pending, count)
+			return m, cmd, true
+		}
+		// Any other key cancels the motion and is handled as usual.
+		count = 0
+	}
+
+	if d, ok := m.countDigit(msg, count); ok {
+		m.count = min(count*10+d, maxCount)
+		return m, nil, true
+	}
+
+	switch {
+	case key.Matches(msg, m.keys.GoToTop):
+		m.count, m.pending = count, &m.keys.GoToTop
+		return m, nil, true
+	case key.Matches(msg, m.keys.CenterCursor):
+		m.pending = &m.keys.CenterCursor
+		return m, nil, true
+	case key.Matches(msg, m.keys.GoToBottom):
+		m, cmd = m.runAction(&m.keys.GoToBottom, count)
+		return m, cmd, true
+	}
+
+	if count > 0 && key.Matches(msg, m.keys.Up, m.keys.Down, m.keys.PrevFile, m.keys.NextFile,
+		m.keys.CtrlD, m.keys.CtrlU, m.keys.PageDown, m.keys.PageUp) {
+		m, cmd = m.runAction(m.keys.mainAction(msg), count)
+		return m, cmd, true
+	}
+	return m, nil, false
+}
+
+// countDigit returns the digit msg adds to the count. Digits from 1 start a
+// count unless they're bound to an action, and 0 can only continue one.
+func (m mainModel) countDigit(msg tea.KeyPressMsg, count int) (int, bool) {
+	d, err := strconv.Atoi(msg.String())
+	if err != nil || d < 0 || d > 9 {
+		return 0, false
+	}
+	if d == 0 {
+		return 0, count > 0
+	}
+	return d, key.Matches(msg, m.keys.Count) && m.keys.mainAction(msg) == nil
+}
+
+// goTo moves the cursor to the nth file of the file tree, or scrolls the
+// diff to its nth line when it's focused. Past the end, the last one is used.
+func (m mainModel) goTo(n int) (mainModel, tea.Cmd) {
+	if m.activePanel == DiffViewerPanel {
+		m.diffViewer.GoToLine(n)
+		return m, nil
+	}
+	if !m.fileTree.GoToFile(n) {
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m, cmd = m.setNodeDiff(m.fileTree.GetCurrNode())
+	m.diffViewer.GoToTop()
+	return m, cmd
+}
+
+// pendingKeys returns the count and the key of the motion being typed, shown
+// in the footer.
+func (m mainModel) pendingKeys() string {
+	s := ""
+	if m.count > 0 {
+		s = strconv.Itoa(m.count)
+	}
+	if m.pending != nil && len(m.pending.Keys()) > 0 {
+		s += m.pending.Keys()[0]
+	}
+	return s
+}
+
+func repeat(n int, f func()) {
+	for range n {
+		f()
+	}
+}
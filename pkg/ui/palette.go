@@ -0,0 +1,222 @@
+package ui
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"charm.land/bubbles/v2/key"
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/filesort"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/palette"
+)
+
+const (
+	paletteMaxWidth  = 80
+	paletteMaxHeight = 18
+	// presetNone is the value of the preset command turning the preset off.
+	presetNone = "none"
+	// exportCommand writes the patch of the selected files, or else of the
+	// files under the cursor, to a path.
+	exportCommand = "export"
+)
+
+// paletteSize returns the size of the palette, inside its border.
+func (m mainModel) paletteSize() (int, int) {
+	return max(0, min(paletteMaxWidth, m.width-6)), max(0, min(paletteMaxHeight, m.height-6))
+}
+
+// openPalette opens the palette with the given input.
+func (m *mainModel) openPalette(input string) tea.Cmd {
+	m.paletteOpen = true
+	m.palette.SetSize(m.paletteSize())
+	cmd := m.palette.Open(m.paletteCommands())
+	if input != "" {
+		m.palette.SetInput(input)
+	}
+	return cmd
+}
+
+// paletteCommands returns the commands taking an argument, followed by the
+// actions of the key map that are bound to a key.
+func (m mainModel) paletteCommands() []palette.Command {
+	files := make([]string, 0, len(m.files))
+	for _, file := range m.fileTree.VisibleFiles() {
+		files = append(files, filenode.GetFileName(file))
+	}
+	presets := append(slices.Sorted(maps.Keys(m.config.Renderer.Delta.Presets)), presetNone)
+
+	commands := []palette.Command{
+		{Name: "goto", Desc: "go to a file, or to a line with path:line", Values: files},
+		{Name: "sort", Desc: "set the sort mode", Values: filesort.Modes},
+		{Name: "preset", Desc: "set the delta preset", Values: presets},
+		{Name: "icons", Desc: "set the icon style", Values: config.Choices["ui.icons"]},
+		{Name: "layout", Desc: "set the layout", Values: Layouts},
+		{Name: exportCommand, Desc: "write the patch of the selected files, or of the node, to a path"},
+	}
+	for _, a := range m.keys.actions() {
+		if a.scope() != "" || !a.binding.Enabled() {
+			continue
+		}
+		commands = append(commands, palette.Command{
+			Name: a.Name,
+			Desc: a.binding.Help().Desc,
+			Key:  a.binding.Help().Key,
+		})
+	}
+	return commands
+}
+
+func (m mainModel) paletteUpdate(msg tea.KeyPressMsg) (mainModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Palette.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Palette.Close):
+		m.paletteOpen = false
+		return m, nil
+	case key.Matches(msg, m.keys.Palette.Run):
+		name, arg, ok := m.palette.Selected()
+		if !ok {
+			return m, nil
+		}
+		m.paletteOpen = false
+		ran, cmd, err := m.runCommand(name, arg)
+		if err != nil {
+			// Keep the palette open to fix the command.
+			m.paletteOpen = true
+			m.palette.SetError(err)
+			return m, nil
+		}
+		return ran, cmd
+	}
+
+	var cmd tea.Cmd
+	m.palette, cmd = m.palette.Update(msg)
+	return m, cmd
+}
+
+// runCommand runs a command of the palette. Actions of the key map take a
+// count as their argument.
+func (m mainModel) runCommand(name, arg string) (mainModel, tea.Cmd, error) {
+	var cmd tea.Cmd
+	switch name {
+	case "goto":
+		return m.goToPath(arg)
+	case "sort":
+		if !slices.Contains(filesort.Modes, arg) {
+			return m, nil, fmt.Errorf("unknown sort mode %q", arg)
+		}
+		m.sortMode = arg
+		m, cmd = m.resortFiles()
+		return m, cmd, nil
+	case "preset":
+		if arg == presetNone {
+			arg = ""
+		}
+		if _, ok := m.config.Renderer.Delta.Presets[arg]; !ok && arg != "" {
+			return m, nil, fmt.Errorf("unknown preset %q", arg)
+		}
+		return m, m.diffViewer.SetPreset(arg), nil
+	case "icons":
+		if !slices.Contains(config.Choices["ui.icons"], arg) {
+			return m, nil, fmt.Errorf("unknown icon style %q", arg)
+		}
+		m.iconStyle = arg
+		m.fileTree.SetIconStyle(arg)
+		return m, nil, nil
+	case "layout":
+		if !slices.Contains(Layouts, arg) {
+			return m, nil, fmt.Errorf("unknown layout %q", arg)
+		}
+		m.config.UI.Layout = arg
+		return m, m.resize(), nil
+	case exportCommand:
+		m, err := m.exportPatch(arg)
+		return m, nil, err
+	}
+
+	a := findAction(m.keys.actions(), name)
+	if a == nil || a.scope() != "" || !a.binding.Enabled() {
+		return m, nil, fmt.Errorf("unknown command %q", name)
+	}
+	count := 0
+	if arg != "" {
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return m, nil, fmt.Errorf("%s only takes a count, got %q", name, arg)
+		}
+		count = min(n, maxCount)
+	}
+	m, cmd = m.runAction(a.binding, count)
+	return m, cmd, nil
+}
+
+// goToPath shows the file at path, or else the one whose path ends with it.
+// A line number after a colon, as in "pkg/ui/tui.go:120", scrolls the diff to
+// the row showing that line of the new file, or else to the hunk holding it.
+func (m mainModel) goToPath(arg string) (mainModel, tea.Cmd, error) {
+	path, line := arg, 0
+	if before, after, ok := strings.Cut(arg, ":"); ok {
+		n, err := strconv.Atoi(after)
+		if err != nil || n < 1 {
+			return m, nil, fmt.Errorf("invalid line %q", after)
+		}
+		path, line = before, n
+	}
+	file := m.findFile(path)
+	if file == nil {
+		return m, nil, fmt.Errorf("no file matches %q", path)
+	}
+
+	var cmd tea.Cmd
+	m.fileTree.SetCursorByPath(filenode.GetFileName(file))
+	if hunk := hunkAt(file, line); hunk >= 0 {
+		m.diffViewer, cmd = m.diffViewer.SetLinePatch(file, hunk, line)
+	} else {
+		m.diffViewer, cmd = m.diffViewer.SetFilePatch(file)
+		m.diffViewer.GoToTop()
+	}
+	return m, cmd, nil
+}
+
+// findFile returns the visible file at path, or else the first one whose path
+// ends with it.
+func (m mainModel) findFile(path string) *gitdiff.File {
+	if path == "" {
+		return nil
+	}
+	files := m.fileTree.VisibleFiles()
+	for _, file := range files {
+		if filenode.GetFileName(file) == path {
+			return file
+		}
+	}
+	for _, file := range files {
+		if strings.HasSuffix(filenode.GetFileName(file), "/"+path) {
+			return file
+		}
+	}
+	return nil
+}
+
+// hunkAt returns the last hunk of file starting at or before line of the new
+// file, or the first one if none does. It returns -1 if line is 0 or the file
+// has no hunks.
+func hunkAt(file *gitdiff.File, line int) int {
+	if line == 0 || len(file.TextFragments) == 0 {
+		return -1
+	}
+	hunk := 0
+	for i, frag := range file.TextFragments {
+		if frag.NewPosition <= int64(line) {
+			hunk = i
+		}
+	}
+	return hunk
+}
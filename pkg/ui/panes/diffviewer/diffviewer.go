@@ -4,24 +4,37 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"unicode"
 
+	"charm.land/bubbles/v2/key"
 	"charm.land/bubbles/v2/viewport"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
 	"github.com/charmbracelet/x/ansi"
 
+	"github.com/dlvhdr/diffnav/pkg/codeowners"
+	"github.com/dlvhdr/diffnav/pkg/config"
 	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/hunknode"
 	"github.com/dlvhdr/diffnav/pkg/icons"
+	"github.com/dlvhdr/diffnav/pkg/patch"
+	"github.com/dlvhdr/diffnav/pkg/theme"
 	"github.com/dlvhdr/diffnav/pkg/ui/common"
 	"github.com/dlvhdr/diffnav/pkg/utils"
 )
 
 const dirHeaderHeight = 3
 
+// selectionPathPrefix starts the cache path of combined diffs of selected files.
+const selectionPathPrefix = "selection:"
+
 type cachedNode struct {
-	path      string
+	path string
+	// title replaces the path in the header of diffs that aren't a directory.
+	title     string
 	files     []*gitdiff.File
 	additions int64
 	deletions int64
@@ -30,21 +43,37 @@ type cachedNode struct {
 
 type nodeCache map[string]*cachedNode
 
-func cacheKey(path string, sideBySide bool) string {
-	if sideBySide {
-		return path + ":sbs"
-	}
-	return path
-}
-
 type Model struct {
 	common.Common
 	vp         viewport.Model
 	file       *cachedNode
 	dir        *cachedNode
+	root       *cachedNode
 	cache      nodeCache
 	sideBySide bool
-	preamble   string
+	// autoWidth is the width from which diffs are shown side by side in the
+	// auto mode, or 0 if the mode is off.
+	autoWidth int
+	// delta holds the delta options from the config, and preset the name of
+	// the preset in use among them.
+	delta    config.DeltaConfig
+	preset   string
+	preamble string
+	// owners holds the CODEOWNERS owners of each file, if the repo has any.
+	owners map[string][]string
+	// pendingHunk is the hunk of the current file to scroll to once its diff
+	// is rendered, or -1, and pendingOffset the row to scroll to, or -1.
+	pendingHunk   int
+	pendingOffset int
+	// pendingLine is the line of the new file to scroll to rather than the
+	// pending hunk, or 0.
+	pendingLine int
+	// selecting is set while the rows of the diff from anchor to cursor are
+	// selected.
+	selecting bool
+	anchor    int
+	cursor    int
+	keys      KeyMap
 }
 
 // SetPreamble stores the preamble text (e.g. commit metadata from git show).
@@ -52,14 +81,27 @@ func (m *Model) SetPreamble(preamble string) {
 	m.preamble = preamble
 }
 
+// SetOwners stores the owners of each file, shown in the file header.
+func (m *Model) SetOwners(owners map[string][]string) {
+	m.owners = owners
+}
+
 func New(sideBySide bool) Model {
 	return Model{
-		vp:         viewport.Model{},
-		sideBySide: sideBySide,
-		cache:      map[string]*cachedNode{},
+		vp:            viewport.Model{},
+		sideBySide:    sideBySide,
+		cache:         map[string]*cachedNode{},
+		pendingHunk:   -1,
+		pendingOffset: -1,
+		keys:          DefaultKeyMap(),
 	}
 }
 
+// SetKeyMap replaces the bindings the viewer reacts to.
+func (m *Model) SetKeyMap(keys KeyMap) {
+	m.keys = keys
+}
+
 func (m Model) Init() tea.Cmd {
 	return nil
 }
@@ -68,15 +110,15 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 	cmds := make([]tea.Cmd, 0)
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "down", "j", "n":
-			break
-		case "up", "k", "N", "p":
-			break
-		default:
-			vp, vpCmd := m.vp.Update(msg)
-			cmds = append(cmds, vpCmd)
-			m.vp = vp
+		switch {
+		case key.Matches(msg, m.keys.HalfPageDown):
+			m.vp.HalfPageDown()
+		case key.Matches(msg, m.keys.HalfPageUp):
+			m.vp.HalfPageUp()
+		case key.Matches(msg, m.keys.PageDown):
+			m.vp.PageDown()
+		case key.Matches(msg, m.keys.PageUp):
+			m.vp.PageUp()
 		}
 
 	case diffContentMsg:
@@ -92,13 +134,21 @@ func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
 			m.cache[msg.cacheKey].diff = diff
 		}
 		m.vp.SetContent(diff)
+		if m.file != nil && msg.cacheKey == m.renderOptions().cacheKey(m.file.path) {
+			m.scrollToPendingHunk()
+		}
+		if node := m.node(); node != nil && m.pendingOffset >= 0 &&
+			msg.cacheKey == m.renderOptions().cacheKey(node.path) {
+			m.vp.SetYOffset(m.pendingOffset)
+			m.pendingOffset = -1
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
 func (m Model) View() string {
-	return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.vp.View())
+	return lipgloss.JoinVertical(lipgloss.Left, m.headerView(), m.selectionView(m.vp.View()))
 }
 
 func (m *Model) SetSize(width, height int) tea.Cmd {
@@ -111,8 +161,10 @@ func (m *Model) SetSize(width, height int) tea.Cmd {
 }
 
 func (m *Model) diff() tea.Cmd {
+	m.selecting = false
+	opts := m.renderOptions()
 	if m.file != nil {
-		key := cacheKey(m.file.path, m.sideBySide)
+		key := opts.cacheKey(m.file.path)
 		if cached, ok := m.cache[key]; ok && cached.diff != "" {
 			m.file = cached
 			m.vp.SetContent(cached.diff)
@@ -126,9 +178,9 @@ func (m *Model) diff() tea.Cmd {
 		}
 		m.file = node
 		m.cache[key] = node
-		return diffFile(node, m.Width, m.sideBySide)
+		return diffFile(node, m.Width, opts)
 	} else if m.dir != nil {
-		key := cacheKey(m.dir.path, m.sideBySide)
+		key := opts.cacheKey(m.dir.path)
 		if cached, ok := m.cache[key]; ok && cached.diff != "" {
 			m.dir = cached
 			m.vp.SetContent(cached.diff)
@@ -136,6 +188,7 @@ func (m *Model) diff() tea.Cmd {
 		}
 		node := &cachedNode{
 			path:      m.dir.path,
+			title:     m.dir.title,
 			files:     m.dir.files,
 			additions: m.dir.additions,
 			deletions: m.dir.deletions,
@@ -146,7 +199,7 @@ func (m *Model) diff() tea.Cmd {
 		if m.dir.path == "/" {
 			preamble = m.preamble
 		}
-		return diffDir(node, m.Width, m.sideBySide, preamble)
+		return diffDir(node, m.Width, opts, preamble)
 	}
 
 	return nil
@@ -160,29 +213,91 @@ func (m Model) headerView() string {
 	if m.file == nil || len(m.file.files) != 1 {
 		return ""
 	}
+	file := m.file.files[0]
 	name := m.file.path
 	base := lipgloss.NewStyle()
 
 	fileIcon := icons.GetIcon(name, false)
 	prefix := base.Render(fileIcon) + base.Render(" ")
-	name = utils.TruncateString(name, m.Width-lipgloss.Width(prefix))
+	if (file.IsRename || file.IsCopy) && file.OldName != "" {
+		name = file.OldName + " → " + name
+	}
+	name = utils.TruncateMiddle(name, m.Width-lipgloss.Width(prefix))
 	top := prefix + base.Bold(true).Render(name)
 
-	bottom := filenode.ViewFileDiffStats(m.file.files[0], base)
+	bottom := filenode.ViewFileDiffStats(file, base)
+	if details := fileDetailsView(file); details != "" {
+		if bottom != "" {
+			bottom += base.Render(" ")
+		}
+		bottom += details
+	}
+	if owners, ok := m.owners[m.file.path]; ok {
+		if bottom != "" {
+			bottom += base.Render(" ")
+		}
+		bottom += ownersView(owners)
+	}
+	bottom = ansi.Truncate(bottom, m.Width, "…")
 
 	return base.
 		Width(m.Width).
 		Height(dirHeaderHeight - 1).
 		BorderStyle(lipgloss.NormalBorder()).
 		BorderBottom(true).
-		BorderForeground(lipgloss.Color("8")).
+		BorderForeground(theme.Current().Muted).
 		Render(lipgloss.JoinVertical(lipgloss.Left, top, bottom))
 }
 
+// ownersView renders the CODEOWNERS owners of a file.
+func ownersView(owners []string) string {
+	dim := lipgloss.NewStyle().Foreground(theme.Current().Muted)
+	if len(owners) == 0 {
+		return dim.Render(codeowners.Unowned)
+	}
+	return dim.Render(" ") + lipgloss.NewStyle().
+		Foreground(theme.Current().Info).
+		Render(strings.Join(owners, " "))
+}
+
+// fileDetailsView describes what else happened to the file besides line
+// changes: renames and copies with their similarity, mode changes, and
+// binary content.
+func fileDetailsView(file *gitdiff.File) string {
+	kind := filenode.GetChangeKind(file)
+	dim := lipgloss.NewStyle().Foreground(theme.Current().Muted)
+	var parts []string
+
+	switch {
+	case file.IsRename:
+		parts = append(parts, lipgloss.NewStyle().Foreground(kind.Color()).Render("renamed"))
+	case file.IsCopy:
+		parts = append(parts, lipgloss.NewStyle().Foreground(kind.Color()).Render("copied"))
+	}
+	if (file.IsRename || file.IsCopy) && file.Score > 0 {
+		parts = append(parts, dim.Render(fmt.Sprintf("%d%% similar", file.Score)))
+	}
+	if filenode.IsModeChange(file) {
+		parts = append(parts, dim.Render(fmt.Sprintf("%s → %s",
+			filenode.FormatMode(file.OldMode), filenode.FormatMode(file.NewMode))))
+	}
+	if file.IsBinary {
+		parts = append(parts, lipgloss.NewStyle().
+			Foreground(filenode.KindBinary.Color()).
+			Render("binary"))
+	}
+
+	return strings.Join(parts, dim.Render(" · "))
+}
+
 func (m Model) dirHeaderView() string {
-	base := lipgloss.NewStyle().Foreground(lipgloss.Blue)
+	base := lipgloss.NewStyle().Foreground(theme.Current().Accent)
 	prefix := base.Render(" ")
-	name := utils.TruncateString(m.dir.path, m.Width-lipgloss.Width(prefix))
+	name := m.dir.path
+	if m.dir.title != "" {
+		name = m.dir.title
+	}
+	name = utils.TruncateString(name, m.Width-lipgloss.Width(prefix))
 
 	top := prefix + base.Bold(true).Render(name)
 	bottom := filenode.ViewDiffStats(m.dir.additions, m.dir.deletions, base)
@@ -191,15 +306,18 @@ func (m Model) dirHeaderView() string {
 		Height(dirHeaderHeight - 1).
 		BorderStyle(lipgloss.NormalBorder()).
 		BorderBottom(true).
-		BorderForeground(lipgloss.Color("8")).
+		BorderForeground(theme.Current().Muted).
 		Render(lipgloss.JoinVertical(lipgloss.Left, top, bottom))
 }
 
 func (m Model) SetFilePatch(file *gitdiff.File) (Model, tea.Cmd) {
 	m.dir = nil
+	m.pendingHunk = -1
+	m.pendingOffset = -1
+	m.selecting = false
 
 	fname := filenode.GetFileName(file)
-	key := cacheKey(fname, m.sideBySide)
+	key := m.renderOptions().cacheKey(fname)
 	if cached, ok := m.cache[key]; ok {
 		m.file = cached
 		m.vp.SetContent(cached.diff)
@@ -217,13 +335,16 @@ func (m Model) SetFilePatch(file *gitdiff.File) (Model, tea.Cmd) {
 	}
 	m.cache[key] = m.file
 
-	return m, diffFile(m.file, m.Width, m.sideBySide)
+	return m, diffFile(m.file, m.Width, m.renderOptions())
 }
 
 func (m Model) SetDirPatch(dirPath string, files []*gitdiff.File) (Model, tea.Cmd) {
 	m.file = nil
+	m.pendingHunk = -1
+	m.pendingOffset = -1
+	m.selecting = false
 
-	key := cacheKey(dirPath, m.sideBySide)
+	key := m.renderOptions().cacheKey(dirPath)
 	if cached, ok := m.cache[key]; ok {
 		m.dir = cached
 		m.vp.SetContent(cached.diff)
@@ -245,21 +366,112 @@ func (m Model) SetDirPatch(dirPath string, files []*gitdiff.File) (Model, tea.Cm
 	m.cache[key] = m.dir
 	preamble := ""
 	if dirPath == "/" {
+		m.root = m.dir
 		preamble = m.preamble
 	}
-	return m, diffDir(m.dir, m.Width, m.sideBySide, preamble)
+	return m, diffDir(m.dir, m.Width, m.renderOptions(), preamble)
+}
+
+// SetSelectionPatch shows the combined diff of an arbitrary set of files.
+func (m Model) SetSelectionPatch(files []*gitdiff.File) (Model, tea.Cmd) {
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, filenode.GetFileName(file))
+	}
+	// Each distinct set of files gets its own cache entry.
+	var cmd tea.Cmd
+	m, cmd = m.SetDirPatch(selectionPathPrefix+strings.Join(names, "\x00"), files)
+	m.dir.title = fmt.Sprintf("%d selected files", len(files))
+	if len(files) == 1 {
+		m.dir.title = "1 selected file"
+	}
+	return m, cmd
+}
+
+// SetHunkPatch shows the diff of the file and scrolls to one of its hunks,
+// as soon as the diff is rendered.
+func (m Model) SetHunkPatch(file *gitdiff.File, hunk int) (Model, tea.Cmd) {
+	return m.SetLinePatch(file, hunk, 0)
+}
+
+// SetLinePatch shows the diff of the file and scrolls to the row showing a
+// line of the new file, as soon as the diff is rendered. When no row shows
+// the line, it scrolls to the hunk instead.
+func (m Model) SetLinePatch(file *gitdiff.File, hunk, line int) (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m, cmd = m.SetFilePatch(file)
+	m.pendingHunk = hunk
+	m.pendingLine = line
+	if m.file.diff != "" {
+		m.scrollToPendingHunk()
+	}
+	return m, cmd
+}
+
+// scrollToPendingHunk scrolls the rendered file diff to the pending line, or
+// else to the pending hunk.
+func (m *Model) scrollToPendingHunk() {
+	if m.pendingHunk < 0 || m.file == nil || len(m.file.files) != 1 {
+		return
+	}
+	file, sideBySide := m.file.files[0], m.renderOptions().fileSideBySide(m.file)
+	if row := lineRow(m.file.diff, file, sideBySide, m.pendingLine); row >= 0 {
+		m.GoToLine(row + 1)
+	} else {
+		offsets := hunkOffsets(m.file.diff, file, sideBySide)
+		if m.pendingHunk < len(offsets) && offsets[m.pendingHunk] >= 0 {
+			m.vp.SetYOffset(offsets[m.pendingHunk])
+		}
+	}
+	m.pendingHunk = -1
+	m.pendingLine = 0
 }
 
 func (m *Model) GoToTop() {
 	m.vp.GotoTop()
 }
 
+// YOffset returns the row of the diff at the top of the viewport.
+func (m *Model) YOffset() int {
+	return m.vp.YOffset()
+}
+
+// SetYOffset scrolls the diff so the given row is at the top, as soon as the
+// diff is rendered.
+func (m *Model) SetYOffset(offset int) {
+	m.pendingHunk = -1
+	if node := m.node(); node != nil && node.diff == "" {
+		m.pendingOffset = offset
+		return
+	}
+	m.vp.SetYOffset(offset)
+}
+
+// node returns the file or directory whose diff is shown.
+func (m *Model) node() *cachedNode {
+	if m.file != nil {
+		return m.file
+	}
+	return m.dir
+}
+
+// GoToLine scrolls the diff so its nth line, counting from 1, is at the top,
+// or as far down as it goes if n is past the end.
+func (m *Model) GoToLine(n int) {
+	m.vp.SetYOffset(n - 1)
+}
+
 // SetSideBySide updates the diff view mode and re-renders.
 func (m *Model) SetSideBySide(sideBySide bool) tea.Cmd {
 	m.sideBySide = sideBySide
 	return m.diff()
 }
 
+// ClearCache drops all rendered diffs so the next patch is rendered again.
+func (m *Model) ClearCache() {
+	m.cache = make(nodeCache)
+}
+
 // ScrollUp scrolls the viewport up by the given number of lines.
 func (m *Model) ScrollUp(lines int) {
 	m.vp.ScrollUp(lines)
@@ -270,24 +482,55 @@ func (m *Model) ScrollDown(lines int) {
 	m.vp.ScrollDown(lines)
 }
 
-func diffFile(node *cachedNode, width int, sideBySide bool) tea.Cmd {
+// HalfPageDown scrolls the viewport down by half its height.
+func (m *Model) HalfPageDown() {
+	m.vp.HalfPageDown()
+}
+
+// HalfPageUp scrolls the viewport up by half its height.
+func (m *Model) HalfPageUp() {
+	m.vp.HalfPageUp()
+}
+
+// PageDown scrolls the viewport down by its height.
+func (m *Model) PageDown() {
+	m.vp.PageDown()
+}
+
+// PageUp scrolls the viewport up by its height.
+func (m *Model) PageUp() {
+	m.vp.PageUp()
+}
+
+// deltaThemeArgs tells delta to use its light syntax theme along with ours. As
+// its output is piped, delta can't detect the terminal's background itself.
+func deltaThemeArgs() []string {
+	if theme.Current().Name == theme.Light {
+		return []string{"--light"}
+	}
+	return nil
+}
+
+func diffFile(node *cachedNode, width int, opts renderOptions) tea.Cmd {
 	if width == 0 || node == nil || len(node.files) != 1 {
 		return nil
 	}
 
 	file := node.files[0]
-	key := cacheKey(node.path, sideBySide)
+	key := opts.cacheKey(node.path)
+	themeArgs := deltaThemeArgs()
 	return func() tea.Msg {
-		// Only use side-by-side if preference is true AND file is not new/deleted
-		useSideBySide := sideBySide && !file.IsNew && !file.IsDelete
+		useSideBySide := opts.fileSideBySide(node)
 		args := []string{
 			"--paging=never",
 			fmt.Sprintf("-w=%d", width),
 			fmt.Sprintf("--max-line-length=%d", width),
 		}
+		args = append(args, themeArgs...)
 		if useSideBySide {
 			args = append(args, "--side-by-side")
 		}
+		args = append(args, opts.deltaArgs(useSideBySide)...)
 		deltac := exec.Command("delta", args...)
 		deltac.Env = os.Environ()
 		deltac.Stdin = strings.NewReader(file.String() + "\n")
@@ -299,31 +542,37 @@ func diffFile(node *cachedNode, width int, sideBySide bool) tea.Cmd {
 	}
 }
 
-func diffDir(dir *cachedNode, width int, sideBySide bool, preamble string) tea.Cmd {
+func diffDir(dir *cachedNode, width int, opts renderOptions, preamble string) tea.Cmd {
 	if width == 0 || dir == nil {
 		return nil
 	}
-	key := cacheKey(dir.path, sideBySide)
+	key := opts.cacheKey(dir.path)
+	// The theme is read here rather than in the command, as it may change
+	// when the config is reloaded.
+	t := theme.Current()
+	themeArgs := deltaThemeArgs()
 	return func() tea.Msg {
-		s := common.BgStyles[common.Selected]
-		c := common.LipglossColorToHex(common.Colors[common.Selected])
-		useSideBySide := sideBySide
+		s := lipgloss.NewStyle().Background(t.Selection)
+		c := common.LipglossColorToHex(t.Selection)
+		useSideBySide := opts.sideBySide && !(opts.auto && isOneSided(dir.additions, dir.deletions))
 		args := []string{
 			"--paging=never",
 			fmt.Sprintf("--file-modified-label=%s",
-				utils.RemoveReset(s.Foreground(lipgloss.Yellow).Render(" "))),
+				utils.RemoveReset(s.Foreground(t.Modified).Render(" "))),
 			fmt.Sprintf("--file-removed-label=%s",
-				utils.RemoveReset(s.Foreground(lipgloss.Red).Render(" "))),
+				utils.RemoveReset(s.Foreground(t.Deleted).Render(" "))),
 			fmt.Sprintf("--file-added-label=%s",
-				utils.RemoveReset(s.Foreground(lipgloss.Green).Render(" "))),
+				utils.RemoveReset(s.Foreground(t.Added).Render(" "))),
 			fmt.Sprintf("--file-style='%s bold %s'", c, c),
 			fmt.Sprintf("--file-decoration-style='%s box %s'", c, c),
 			fmt.Sprintf("-w=%d", width),
 			fmt.Sprintf("--max-line-length=%d", width),
 		}
+		args = append(args, themeArgs...)
 		if useSideBySide {
 			args = append(args, "--side-by-side")
 		}
+		args = append(args, opts.deltaArgs(useSideBySide)...)
 		deltac := exec.Command("delta", args...)
 		deltac.Env = os.Environ()
 		strs := strings.Builder{}
@@ -344,14 +593,148 @@ func diffDir(dir *cachedNode, width int, sideBySide bool, preamble string) tea.C
 	}
 }
 
+// hunkHeaderLines is how many rows above a hunk's first line are kept in view
+// when scrolling to it, leaving room for the hunk header.
+const hunkHeaderLines = 2
+
+// minNeedleLen is the shortest line used to find a hunk in the rendered diff.
+// Shorter lines such as "}" are too common to be told apart.
+const minNeedleLen = 6
+
+// maxNeedleLen limits the part of a line that is searched for, as delta may
+// truncate long lines, and halves them in side-by-side mode.
+const maxNeedleLen = 16
+
+// TopLine returns the file of the diff, along with the line and column of
+// the new file at the top of the viewport. The file is nil when the diff
+// shows several files, and the line 0 when the top row is above the hunks.
+// Rows showing no line, such as hunk headers, stand for the line below them.
+func (m *Model) TopLine() (*gitdiff.File, int, int) {
+	if m.file == nil || len(m.file.files) != 1 {
+		return nil, 0, 0
+	}
+	file := m.file.files[0]
+	rows, _ := rowLines(m.file.diff, file, m.renderOptions().fileSideBySide(m.file))
+	top := m.vp.YOffset()
+	first := slices.IndexFunc(rows, func(lines []patch.Line) bool { return len(lines) > 0 })
+	if first < 0 || top < first-hunkHeaderLines {
+		return file, 0, 0
+	}
+	for _, lines := range rows[min(top, len(rows)):] {
+		if len(lines) == 0 {
+			continue
+		}
+		// The last line of a row is the added one of a side-by-side row.
+		l := lines[len(lines)-1]
+		line, column := hunknode.Position(file.TextFragments[l.Hunk], l.Index)
+		return file, line, column
+	}
+	return file, 0, 0
+}
+
+// lineRow returns the first row of the rendered diff showing the given line
+// of the new file, or -1 if none does for sure.
+func lineRow(rendered string, file *gitdiff.File, sideBySide bool, line int) int {
+	if line < 1 {
+		return -1
+	}
+	rows, unsure := rowLines(rendered, file, sideBySide)
+	for row, lines := range rows {
+		if unsure[row] {
+			continue
+		}
+		for _, l := range lines {
+			frag := file.TextFragments[l.Hunk]
+			if frag.Lines[l.Index].Op == gitdiff.OpDelete {
+				continue
+			}
+			if n, _ := hunknode.Position(frag, l.Index); n == line {
+				return row
+			}
+		}
+	}
+	return -1
+}
+
+// hunkOffsets returns the row where each hunk of the file starts in the
+// rendered diff, or -1 for hunks that couldn't be found. As delta's output
+// depends on the user's config, hunks are found by searching for their first
+// distinctive line, ignoring styling and whitespace, and laid out as in
+// side-by-side mode when sideBySide is set.
+func hunkOffsets(rendered string, file *gitdiff.File, sideBySide bool) []int {
+	offsets := needleRows(rendered, file, sideBySide)
+	for i, frag := range file.TextFragments {
+		if offsets[i] >= 0 {
+			_, before := hunkNeedle(frag)
+			offsets[i] = max(0, offsets[i]-layoutRow(hunkLayout(frag, sideBySide), before)-hunkHeaderLines)
+		}
+	}
+	return offsets
+}
+
+// needleRows returns the row of the first distinctive line of each hunk of
+// the file in the rendered diff, or -1 for hunks that couldn't be found. Each
+// hunk is searched for below the rows of the previous one.
+func needleRows(rendered string, file *gitdiff.File, sideBySide bool) []int {
+	rows := strings.Split(ansi.Strip(rendered), "\n")
+	for i, row := range rows {
+		rows[i] = stripSpaces(row)
+	}
+
+	found := make([]int, len(file.TextFragments))
+	start := 0
+	for i, frag := range file.TextFragments {
+		found[i] = -1
+		needle, _ := hunkNeedle(frag)
+		if needle == "" {
+			continue
+		}
+		layout := hunkLayout(frag, sideBySide)
+		_, before := hunkNeedle(frag)
+		for r := start; r < len(rows); r++ {
+			if strings.Contains(rows[r], needle) {
+				found[i] = r
+				start = max(r+1, r-layoutRow(layout, before)+len(layout))
+				break
+			}
+		}
+	}
+	return found
+}
+
+// hunkNeedle returns the text of the hunk's first distinctive line, and how
+// many lines come before it in the hunk.
+func hunkNeedle(frag *gitdiff.TextFragment) (string, int) {
+	for i, line := range frag.Lines {
+		needle := stripSpaces(line.Line)
+		if len([]rune(needle)) < minNeedleLen {
+			continue
+		}
+		if runes := []rune(needle); len(runes) > maxNeedleLen {
+			needle = string(runes[:maxNeedleLen])
+		}
+		return needle, i
+	}
+	return "", 0
+}
+
+func stripSpaces(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
 func renderPreamble(preamble string) string {
 	preamble = strings.TrimSpace(preamble)
 	if preamble == "" {
 		return ""
 	}
 
-	dim := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
-	yellow := lipgloss.NewStyle().Foreground(lipgloss.Yellow)
+	dim := lipgloss.NewStyle().Foreground(theme.Current().Muted)
+	yellow := lipgloss.NewStyle().Foreground(theme.Current().Modified)
 
 	var out []string
 	for _, line := range strings.Split(preamble, "\n") {
@@ -379,9 +762,14 @@ type diffContentMsg struct {
 }
 
 func (m *Model) RootDiffStats() (int64, int64) {
-	if item, ok := m.cache[cacheKey("/", m.sideBySide)]; ok {
+	if item, ok := m.cache[m.renderOptions().cacheKey("/")]; ok {
 		return item.additions, item.deletions
 	}
 
+	// The cache may have been cleared since the root was last rendered.
+	if m.root != nil {
+		return m.root.additions, m.root.deletions
+	}
+
 	return 0, 0
 }
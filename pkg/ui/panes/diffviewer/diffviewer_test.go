@@ -1,10 +1,19 @@
 package diffviewer
 
 import (
+	"math"
+	"os"
+	"slices"
 	"strings"
 	"testing"
 
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
 	"github.com/charmbracelet/x/ansi"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/patch"
 )
 
 func TestRenderPreamble_Empty(t *testing.T) {
@@ -62,3 +71,454 @@ Date:   Mon Jan 1 00:00:00 2026 +0000
 		}
 	}
 }
+
+func TestHunkOffsets(t *testing.T) {
+	data, err := os.ReadFile("../../../../examples/gh_dash_pr.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[3]
+	if len(file.TextFragments) < 2 {
+		t.Fatalf("expected a file with several hunks, got %d", len(file.TextFragments))
+	}
+
+	// Style every row and expand tabs the way delta would.
+	var expected []int
+	rows := strings.Split(file.String(), "\n")
+	for i, row := range rows {
+		if strings.HasPrefix(row, "@@") {
+			expected = append(expected, max(0, i+1-hunkHeaderLines))
+		}
+		rows[i] = "\x1b[32m" + strings.ReplaceAll(row, "\t", "    ") + "\x1b[0m"
+	}
+
+	got := hunkOffsets(strings.Join(rows, "\n"), file, false)
+	if !slices.Equal(got, expected) {
+		t.Fatalf("expected hunk offsets %v, got %v", expected, got)
+	}
+}
+
+func TestDeltaArgs(t *testing.T) {
+	opts := renderOptions{
+		delta: config.DeltaConfig{
+			Args:       []string{"--navigate"},
+			Features:   "decorations",
+			Unified:    config.DeltaOptions{Args: []string{"--line-numbers"}},
+			SideBySide: config.DeltaOptions{Features: "wide"},
+			Presets: map[string]config.DeltaOptions{
+				"compact": {Args: []string{"--hunk-header-style=omit"}, Features: "tight"},
+			},
+		},
+		preset: "compact",
+	}
+
+	got := opts.deltaArgs(false)
+	want := []string{"--navigate", "--line-numbers", "--hunk-header-style=omit", "--features=decorations tight"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("unified args: got %q, want %q", got, want)
+	}
+	got = opts.deltaArgs(true)
+	want = []string{"--navigate", "--hunk-header-style=omit", "--features=decorations wide tight"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("side-by-side args: got %q, want %q", got, want)
+	}
+	if got := (renderOptions{}).deltaArgs(true); len(got) != 0 {
+		t.Fatalf("expected no args without config, got %q", got)
+	}
+}
+
+func TestCyclePresetCachesSeparately(t *testing.T) {
+	m := New(false)
+	m.SetDeltaConfig(config.DeltaConfig{Presets: map[string]config.DeltaOptions{
+		"line-numbers": {Args: []string{"--line-numbers"}},
+		"compact":      {Args: []string{"--hunk-header-style=omit"}},
+	}})
+	keys := []string{m.renderOptions().cacheKey("/")}
+	for _, want := range []string{"compact", "line-numbers", ""} {
+		m.CyclePreset()
+		if got := m.Preset(); got != want {
+			t.Fatalf("expected preset %q, got %q", want, got)
+		}
+		keys = append(keys, m.renderOptions().cacheKey("/"))
+	}
+	if keys[0] != keys[3] || keys[0] == keys[1] || keys[1] == keys[2] {
+		t.Fatalf("expected each preset to have its own cache key, got %q", keys)
+	}
+
+	m.SetDeltaConfig(config.DeltaConfig{})
+	if m.Preset() != "" || m.CyclePreset() != nil || m.Preset() != "" {
+		t.Fatal("expected no preset once the presets are removed")
+	}
+}
+
+func TestAutoSideBySide(t *testing.T) {
+	m := New(true)
+	m.SetAutoSideBySide(120)
+
+	m.SetSize(100, 40)
+	if m.SideBySide() {
+		t.Fatal("expected a narrow viewer to be unified in the auto mode")
+	}
+	m.SetSize(140, 40)
+	if !m.SideBySide() {
+		t.Fatal("expected a wide viewer to be side-by-side in the auto mode")
+	}
+	auto := m.renderOptions().cacheKey("/")
+
+	m.SetAutoSideBySide(0)
+	m.SetSize(100, 40)
+	if !m.SideBySide() {
+		t.Fatal("expected the preference to be used with the auto mode off")
+	}
+	if m.renderOptions().cacheKey("/") == auto {
+		t.Fatal("expected diffs rendered in the auto mode to be cached separately")
+	}
+}
+
+func TestIsOneSided(t *testing.T) {
+	tests := []struct {
+		additions, deletions int64
+		want                 bool
+	}{
+		{0, 0, false},
+		{10, 0, true},
+		{0, 3, true},
+		{95, 5, true},
+		{80, 20, false},
+		{5, 5, false},
+	}
+	for _, tt := range tests {
+		if got := isOneSided(tt.additions, tt.deletions); got != tt.want {
+			t.Errorf("isOneSided(%d, %d) = %v, want %v", tt.additions, tt.deletions, got, tt.want)
+		}
+	}
+}
+
+func TestPageMotions(t *testing.T) {
+	m := New(false)
+	m.SetSize(80, 10+dirHeaderHeight)
+	m.vp.SetContent(strings.Repeat("line\n", 100))
+
+	m, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: 'f', Mod: tea.ModCtrl}))
+	if got := m.vp.YOffset(); got != m.vp.Height() {
+		t.Fatalf("expected ctrl+f to scroll a page down, got offset %d", got)
+	}
+	m, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: 'b', Mod: tea.ModCtrl}))
+	if got := m.vp.YOffset(); got != 0 {
+		t.Fatalf("expected ctrl+b to scroll a page up, got offset %d", got)
+	}
+
+	m.GoToLine(20)
+	if got := m.vp.YOffset(); got != 19 {
+		t.Fatalf("expected line 20 at the top, got offset %d", got)
+	}
+	m.GoToLine(math.MaxInt)
+	if !m.vp.AtBottom() {
+		t.Fatal("expected a line past the end to scroll to the bottom")
+	}
+}
+
+func TestRowLines(t *testing.T) {
+	data, err := os.ReadFile("../../../../examples/gh_dash_pr.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[3]
+
+	// Rendered as is, each line of a hunk is on the row after its header.
+	rendered := file.String()
+	rows, _ := rowLines(rendered, file, false)
+	hunk := -1
+	for r, row := range strings.Split(rendered, "\n") {
+		switch {
+		case strings.HasPrefix(row, "@@"):
+			hunk++
+			if len(rows[r]) != 0 {
+				t.Fatalf("expected no lines on the header of hunk %d, got %v", hunk, rows[r])
+			}
+		case hunk >= 0 && r < len(rows) && len(rows[r]) == 1:
+			l := rows[r][0]
+			line := file.TextFragments[l.Hunk].Lines[l.Index]
+			if l.Hunk != hunk || line.Op.String()+strings.TrimSuffix(line.Line, "\n") != row {
+				t.Fatalf("expected row %d %q to be its line, got %+v", r, row, l)
+			}
+		}
+	}
+
+	// Side by side, removed lines are beside the added lines that follow.
+	frag := &gitdiff.TextFragment{Lines: []gitdiff.Line{
+		{Op: gitdiff.OpContext}, {Op: gitdiff.OpDelete}, {Op: gitdiff.OpDelete},
+		{Op: gitdiff.OpAdd}, {Op: gitdiff.OpContext}, {Op: gitdiff.OpAdd},
+	}}
+	got := hunkLayout(frag, true)
+	want := [][]int{{0}, {1, 3}, {2}, {4}, {5}}
+	if !slices.EqualFunc(got, want, slices.Equal) {
+		t.Fatalf("expected the side-by-side layout %v, got %v", want, got)
+	}
+}
+
+func TestSelection(t *testing.T) {
+	files, _, err := gitdiff.Parse(strings.NewReader(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,3 +1,3 @@
+ first line
+-second line
++second line changed
+ third line
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[0]
+
+	m := New(false)
+	m.SetSize(80, 10+dirHeaderHeight)
+	m, _ = m.SetFilePatch(file)
+	if m.StartSelection(-1) {
+		t.Fatal("expected no selection before the diff is rendered")
+	}
+	key := m.renderOptions().cacheKey("a.txt")
+	m, _ = m.Update(diffContentMsg{cacheKey: key, text: "a.txt\n\n" + file.TextFragments[0].String()})
+
+	if !m.StartSelection(-1) {
+		t.Fatal("expected the diff of a file to be selectable")
+	}
+	m.MoveSelection(1)
+	got, lines, err := m.SelectedLines()
+	if err != nil || got != file || !slices.Equal(lines, []patch.Line{{Hunk: 0, Index: 0}, {Hunk: 0, Index: 1}}) {
+		t.Fatalf("expected the first two lines to be selected, got %v", lines)
+	}
+	if view := ansi.Strip(m.View()); !strings.Contains(view, "-second line") {
+		t.Fatalf("expected the selected rows to be shown, got:\n%s", view)
+	}
+
+	m, _ = m.SetFilePatch(file)
+	if m.Selecting() {
+		t.Fatal("expected showing another diff to clear the selection")
+	}
+}
+
+// renderHunks renders the hunks of the file one row per row of their layout,
+// side by side rows being split by a bar, as delta lays them out.
+func renderHunks(file *gitdiff.File, sideBySide bool) string {
+	var rows []string
+	for _, frag := range file.TextFragments {
+		rows = append(rows, "", frag.Header())
+		for _, indexes := range hunkLayout(frag, sideBySide) {
+			var sides []string
+			for _, i := range indexes {
+				line := frag.Lines[i]
+				sides = append(sides, line.Op.String()+strings.TrimSuffix(line.Line, "\n"))
+			}
+			rows = append(rows, strings.Join(sides, " │ "))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+func TestHunkOffsetsSearchBelowEachHunk(t *testing.T) {
+	files, _, err := gitdiff.Parse(strings.NewReader(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,3 @@
+ the first line
+-removed line one
+-removed line two
++the added line
+ the shared line
+@@ -20,2 +19,3 @@
+ the shared line
++the added line again
+ the last line
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[0]
+	for _, sideBySide := range []bool{false, true} {
+		// Each hunk starts on the row after its header, and the first one
+		// takes a row less side by side.
+		first := 5
+		if sideBySide {
+			first = 4
+		}
+		want := []int{max(0, 2-hunkHeaderLines), 2 + first + 2 - hunkHeaderLines}
+		if got := hunkOffsets(renderHunks(file, sideBySide), file, sideBySide); !slices.Equal(got, want) {
+			t.Errorf("sideBySide=%v: expected hunk offsets %v, got %v", sideBySide, want, got)
+		}
+	}
+}
+
+func TestRowLinesWrappedLines(t *testing.T) {
+	files, _, err := gitdiff.Parse(strings.NewReader(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1,4 +1,4 @@
+ first line of the file
+-a removed line that is long enough to wrap in a narrow column
++an added line
+ the last line
+ trailing context
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[0]
+	rendered := renderHunks(file, true)
+	long := "-a removed line that is long enough to wrap in a narrow column │ +an added line"
+	if !strings.Contains(rendered, long) {
+		t.Fatalf("expected the long line to be rendered, got:\n%s", rendered)
+	}
+	want := [][]patch.Line{
+		nil, nil,
+		{{Hunk: 0, Index: 0}},
+		{{Hunk: 0, Index: 1}, {Hunk: 0, Index: 2}},
+		{{Hunk: 0, Index: 1}, {Hunk: 0, Index: 2}},
+		{{Hunk: 0, Index: 3}},
+		{{Hunk: 0, Index: 4}},
+	}
+
+	for _, symbol := range []string{"↵", ""} {
+		wrapped := strings.Replace(rendered, long,
+			"-a removed line that is long"+symbol+" │ +an added line\n enough to wrap in a narrow column │", 1)
+		rows, unsure := rowLines(wrapped, file, true)
+		if !slices.EqualFunc(rows, want, slices.Equal) || slices.Contains(unsure, true) {
+			t.Errorf("symbol %q: expected both rows of the wrapped line to show it, got %v (unsure %v)", symbol, rows, unsure)
+		}
+	}
+
+	// Rows that don't show the lines they should can't be selected.
+	garbled := strings.Replace(rendered, "the last line", "something else", 1)
+	rows, unsure := rowLines(garbled, file, true)
+	if slices.Contains(rows[5], patch.Line{Hunk: 0, Index: 3}) || !unsure[5] {
+		t.Errorf("expected the garbled row to be unsure, got %v (unsure %v)", rows, unsure)
+	}
+}
+
+func TestTopLineSideBySide(t *testing.T) {
+	files, _, err := gitdiff.Parse(strings.NewReader(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -10,6 +10,5 @@
+ the first line of the hunk
+-removed line number one
+-removed line number two, which is long enough to wrap
++the line added instead
+ the context after it
+ more context below
+ the last line of the hunk
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[0]
+	rendered := strings.Replace(renderHunks(file, true),
+		"-removed line number two, which is long enough to wrap",
+		"-removed line number two,↵\n which is long enough to wrap", 1)
+
+	m := New(true)
+	m.SetSize(80, 1+dirHeaderHeight)
+	m, _ = m.SetFilePatch(file)
+	m, _ = m.Update(diffContentMsg{cacheKey: m.renderOptions().cacheKey("a.txt"), text: rendered})
+
+	// Rows: the blank one and the header, then the first line, the removed
+	// lines beside the added one, the wrap of the second removed line, and
+	// the context.
+	tests := []struct{ row, line int }{
+		{row: 0, line: 10},
+		{row: 1, line: 10},
+		{row: 2, line: 10},
+		{row: 3, line: 11},
+		{row: 4, line: 11},
+		{row: 5, line: 11},
+		{row: 6, line: 12},
+		{row: 8, line: 14},
+	}
+	for _, tt := range tests {
+		m.vp.SetYOffset(tt.row)
+		if got, line, _ := m.TopLine(); got != file || line != tt.line {
+			t.Errorf("row %d: expected line %d, got %d", tt.row, tt.line, line)
+		}
+	}
+}
+
+func TestSetLinePatchScrollsToLine(t *testing.T) {
+	files, _, err := gitdiff.Parse(strings.NewReader(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -10,6 +10,5 @@
+ the first line of the hunk
+-removed line number one
+-removed line number two
++the line added instead
+ the context after it
+ more context below
+ the last line of the hunk
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := files[0]
+	render := func(m Model) Model {
+		m, _ = m.Update(diffContentMsg{cacheKey: m.renderOptions().cacheKey("a.txt"), text: renderHunks(file, false)})
+		return m
+	}
+
+	// Rows: the blank one and the header, the first line, the removed and
+	// added lines, then the context from line 12.
+	m := New(false)
+	m.SetSize(80, 1+dirHeaderHeight)
+	m, _ = m.SetLinePatch(file, 0, 13)
+	m = render(m)
+	if got := m.YOffset(); got != 7 {
+		t.Fatalf("expected line 13 at the top, at row 7, got row %d", got)
+	}
+
+	// A line outside the hunks goes to the hunk holding it.
+	m, _ = m.SetLinePatch(file, 0, 40)
+	if got := m.YOffset(); got != 0 {
+		t.Fatalf("expected the top of the hunk, got row %d", got)
+	}
+}
+
+func TestOnlyBoundKeysScroll(t *testing.T) {
+	files, _, err := gitdiff.Parse(strings.NewReader(`diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1 +1 @@
+-old
++new
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(false)
+	m.SetSize(80, 5+dirHeaderHeight)
+	m, _ = m.SetFilePatch(files[0])
+	m, _ = m.Update(diffContentMsg{cacheKey: m.renderOptions().cacheKey("a.txt"), text: strings.Repeat("row\n", 50)})
+
+	// The viewport's own keys are left to other actions.
+	for _, k := range []string{"j", "k", "f", "b", "d", "u", "h", "l", " "} {
+		m, _ = m.Update(tea.KeyPressMsg(tea.Key{Text: k, Code: rune(k[0])}))
+		if got := m.YOffset(); got != 0 {
+			t.Fatalf("expected %q not to scroll the diff, got row %d", k, got)
+		}
+	}
+
+	keys := DefaultKeyMap()
+	keys.PageDown = key.NewBinding(key.WithKeys("f"))
+	m.SetKeyMap(keys)
+	m, _ = m.Update(tea.KeyPressMsg(tea.Key{Text: "f", Code: 'f'}))
+	if got := m.YOffset(); got != 5 {
+		t.Fatalf("expected f to page down once bound, got row %d", got)
+	}
+}
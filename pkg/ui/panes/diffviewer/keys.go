@@ -0,0 +1,22 @@
+package diffviewer
+
+import "charm.land/bubbles/v2/key"
+
+// KeyMap holds the bindings the diff viewer scrolls with. Other keys are
+// handled by the main model, and the viewport's own key map is never used,
+// so that its keys can be bound to other actions.
+type KeyMap struct {
+	HalfPageDown key.Binding
+	HalfPageUp   key.Binding
+	PageDown     key.Binding
+	PageUp       key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		HalfPageDown: key.NewBinding(key.WithKeys("ctrl+d")),
+		HalfPageUp:   key.NewBinding(key.WithKeys("ctrl+u")),
+		PageDown:     key.NewBinding(key.WithKeys("ctrl+f")),
+		PageUp:       key.NewBinding(key.WithKeys("ctrl+b")),
+	}
+}
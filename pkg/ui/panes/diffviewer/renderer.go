@@ -0,0 +1,148 @@
+package diffviewer
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+)
+
+// renderOptions are the options a diff is rendered with. They're read from
+// the model when the render starts, and each combination of them is cached
+// separately.
+type renderOptions struct {
+	sideBySide bool
+	// auto is set when sideBySide was picked by the auto mode, which also
+	// shows one-sided changes as unified.
+	auto   bool
+	delta  config.DeltaConfig
+	preset string
+}
+
+func (m *Model) renderOptions() renderOptions {
+	return renderOptions{sideBySide: m.SideBySide(), auto: m.autoWidth > 0, delta: m.delta, preset: m.preset}
+}
+
+// oneSidedRatio is the share of the changed lines above which changes are
+// shown as unified in the auto mode, as half of a side-by-side diff would be
+// left blank.
+const oneSidedRatio = 0.9
+
+// isOneSided reports whether almost all the changed lines are additions, or
+// almost all are deletions.
+func isOneSided(additions, deletions int64) bool {
+	total := additions + deletions
+	if total == 0 {
+		return false
+	}
+	return float64(max(additions, deletions)) >= oneSidedRatio*float64(total)
+}
+
+// fileSideBySide reports whether the diff of a single file is shown side by
+// side: new and deleted files aren't, nor are one-sided ones in the auto
+// mode.
+func (o renderOptions) fileSideBySide(node *cachedNode) bool {
+	file := node.files[0]
+	return o.sideBySide && !file.IsNew && !file.IsDelete &&
+		!(o.auto && isOneSided(node.additions, node.deletions))
+}
+
+// SetAutoSideBySide turns on the auto mode, in which diffs are shown side by
+// side when the viewer is at least minWidth wide, or turns it off if minWidth
+// is 0. It takes effect on the next render.
+func (m *Model) SetAutoSideBySide(minWidth int) {
+	m.autoWidth = minWidth
+}
+
+// AutoSideBySide reports whether the auto mode is on.
+func (m *Model) AutoSideBySide() bool {
+	return m.autoWidth > 0
+}
+
+// SideBySide reports whether diffs are shown side by side, which in the auto
+// mode depends on the width of the viewer.
+func (m *Model) SideBySide() bool {
+	if m.autoWidth > 0 {
+		return m.Width >= m.autoWidth
+	}
+	return m.sideBySide
+}
+
+// cacheKey returns the key of the diff of path rendered with o.
+func (o renderOptions) cacheKey(path string) string {
+	key := path
+	if o.sideBySide {
+		key += ":sbs"
+		if o.auto {
+			key += ":auto"
+		}
+	}
+	if o.preset != "" {
+		key += ":" + o.preset
+	}
+	return key
+}
+
+// deltaArgs returns the args from the config for a diff shown side by side
+// or not: the common args, then the ones of the view mode and the preset.
+// Their features are combined into a single --features arg.
+func (o renderOptions) deltaArgs(sideBySide bool) []string {
+	mode := o.delta.Unified
+	if sideBySide {
+		mode = o.delta.SideBySide
+	}
+	layers := []config.DeltaOptions{
+		{Args: o.delta.Args, Features: o.delta.Features},
+		mode,
+		o.delta.Presets[o.preset],
+	}
+
+	var args, features []string
+	for _, layer := range layers {
+		args = append(args, layer.Args...)
+		features = append(features, strings.Fields(layer.Features)...)
+	}
+	if len(features) > 0 {
+		args = append(args, "--features="+strings.Join(features, " "))
+	}
+	return args
+}
+
+// SetDeltaConfig sets the delta options from the config and drops the
+// rendered diffs. A preset that's no longer defined is turned off.
+func (m *Model) SetDeltaConfig(delta config.DeltaConfig) {
+	m.delta = delta
+	if _, ok := delta.Presets[m.preset]; !ok {
+		m.preset = ""
+	}
+	m.ClearCache()
+}
+
+// Preset returns the name of the preset in use, or "" if none is.
+func (m *Model) Preset() string {
+	return m.preset
+}
+
+// CyclePreset switches to the next preset by name, or back to none after the
+// last one, and renders the diff with it.
+func (m *Model) CyclePreset() tea.Cmd {
+	names := slices.Sorted(maps.Keys(m.delta.Presets))
+	if len(names) == 0 {
+		return nil
+	}
+	i := slices.Index(names, m.preset)
+	if i+1 < len(names) {
+		return m.SetPreset(names[i+1])
+	}
+	return m.SetPreset("")
+}
+
+// SetPreset switches to the preset of the given name, or to none if name is
+// "", and renders the diff with it.
+func (m *Model) SetPreset(name string) tea.Cmd {
+	m.preset = name
+	return m.diff()
+}
@@ -0,0 +1,351 @@
+package diffviewer
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"charm.land/lipgloss/v2"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/charmbracelet/x/ansi"
+
+	"github.com/dlvhdr/diffnav/pkg/patch"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+)
+
+// StartSelection selects the row of the diff at the given row, or the first
+// row of a diff line from the top of the viewport if row is negative. Only
+// the diff of a single file can be selected in, so it reports whether one is
+// shown.
+func (m *Model) StartSelection(row int) bool {
+	file := m.selectableFile()
+	if file == nil {
+		return false
+	}
+	if row < 0 {
+		row = m.vp.YOffset()
+		rows, _ := m.fileRows()
+		for r := row; r < len(rows); r++ {
+			if len(rows[r]) > 0 {
+				row = r
+				break
+			}
+		}
+	}
+	m.selecting = true
+	m.anchor = m.clampRow(row)
+	m.cursor = m.anchor
+	m.scrollToCursor()
+	return true
+}
+
+// Selecting reports whether rows of the diff are selected.
+func (m *Model) Selecting() bool {
+	return m.selecting
+}
+
+// ClearSelection leaves the selection mode.
+func (m *Model) ClearSelection() {
+	m.selecting = false
+}
+
+// MoveSelection moves the end of the selection by the given number of rows,
+// scrolling to keep it in view.
+func (m *Model) MoveSelection(rows int) {
+	m.SetSelectionEnd(m.cursor + rows)
+}
+
+// SetSelectionEnd moves the end of the selection to the given row, scrolling
+// to keep it in view.
+func (m *Model) SetSelectionEnd(row int) {
+	if !m.selecting {
+		return
+	}
+	m.cursor = m.clampRow(row)
+	m.scrollToCursor()
+}
+
+// RowAt returns the row of the diff at the given line of the view. Lines
+// above the diff are above its top row.
+func (m *Model) RowAt(y int) int {
+	return y - dirHeaderHeight + m.vp.YOffset()
+}
+
+// SelectedRows returns how many rows are selected.
+func (m *Model) SelectedRows() int {
+	if !m.selecting {
+		return 0
+	}
+	lo, hi := m.selectedRange()
+	return hi - lo + 1
+}
+
+// SelectedLines returns the file of the diff and its lines shown by the
+// selected rows. Rows showing two lines side by side select both. It fails
+// when some selected rows couldn't be told apart in the rendered diff, rather
+// than returning other lines than the ones shown.
+func (m *Model) SelectedLines() (*gitdiff.File, []patch.Line, error) {
+	file := m.selectableFile()
+	if !m.selecting || file == nil {
+		return nil, nil, nil
+	}
+	rows, unsure := m.fileRows()
+	lo, hi := m.selectedRange()
+	var lines []patch.Line
+	for r := lo; r <= hi && r < len(rows); r++ {
+		if unsure[r] {
+			return file, nil, errors.New("the selected rows couldn't be matched to the lines of the diff")
+		}
+		lines = append(lines, rows[r]...)
+	}
+	return file, lines, nil
+}
+
+// selectableFile returns the file of the diff if it shows a single rendered
+// file, or nil.
+func (m *Model) selectableFile() *gitdiff.File {
+	if m.file == nil || len(m.file.files) != 1 || m.file.diff == "" {
+		return nil
+	}
+	return m.file.files[0]
+}
+
+// fileRows returns the lines shown by each row of the diff of the file, and
+// the rows that may show lines that couldn't be placed.
+func (m *Model) fileRows() ([][]patch.Line, []bool) {
+	return rowLines(m.file.diff, m.file.files[0], m.renderOptions().fileSideBySide(m.file))
+}
+
+func (m *Model) selectedRange() (int, int) {
+	return min(m.anchor, m.cursor), max(m.anchor, m.cursor)
+}
+
+func (m *Model) clampRow(row int) int {
+	return max(0, min(row, m.vp.TotalLineCount()-1))
+}
+
+func (m *Model) scrollToCursor() {
+	switch top := m.vp.YOffset(); {
+	case m.cursor < top:
+		m.vp.SetYOffset(m.cursor)
+	case m.cursor >= top+m.vp.Height():
+		m.vp.SetYOffset(m.cursor - m.vp.Height() + 1)
+	}
+}
+
+// selectionView highlights the selected rows of the viewport's view, dropping
+// their styling.
+func (m *Model) selectionView(view string) string {
+	if !m.selecting {
+		return view
+	}
+	style := lipgloss.NewStyle().
+		Background(theme.Current().Selection).
+		Width(m.vp.Width()).
+		MaxWidth(m.vp.Width())
+	lo, hi := m.selectedRange()
+	rows := strings.Split(view, "\n")
+	for i, row := range rows {
+		if r := m.vp.YOffset() + i; r >= lo && r <= hi {
+			rows[i] = style.Render(ansi.Strip(row))
+		}
+	}
+	return strings.Join(rows, "\n")
+}
+
+// wrapSymbols are the symbols delta ends a row with, by default, when its
+// line goes on in the next row.
+const wrapSymbols = "↵↴"
+
+// maxWrappedRows is how many rows below the one it's expected on a line is
+// looked for, in case the line before it wraps without delta's symbols.
+const maxWrappedRows = 3
+
+// lineMatchLen is the length of the start of a line checked to be on its
+// row, short enough not to be cut by a wrap.
+const lineMatchLen = 8
+
+// rowLines returns the lines of the file's hunks shown by each row of its
+// rendered diff, and the rows that may show lines that couldn't be placed.
+// Rows are laid out from the first distinctive line of each hunk, one line
+// per row, or with removed lines beside the added ones that follow them in
+// side-by-side mode, as delta does. Each row is checked to show the start of
+// its lines, and the rows below one delta wraps show its lines too.
+func rowLines(rendered string, file *gitdiff.File, sideBySide bool) ([][]patch.Line, []bool) {
+	rows := strings.Split(ansi.Strip(rendered), "\n")
+	for i, row := range rows {
+		rows[i] = stripSpaces(row)
+	}
+	res := make([][]patch.Line, len(rows))
+	unsure := make([]bool, len(rows))
+
+	// first and last are the rows each hunk spans, or -1 for hunks that
+	// couldn't be found.
+	needles := needleRows(rendered, file, sideBySide)
+	first := make([]int, len(needles))
+	last := make([]int, len(needles))
+	complete := make([]bool, len(needles))
+	for i, frag := range file.TextFragments {
+		first[i], last[i] = -1, -1
+		if needles[i] < 0 {
+			continue
+		}
+		layout := hunkLayout(frag, sideBySide)
+		_, before := hunkNeedle(frag)
+		starts, ends, ok := placeHunk(rows, frag, layout, layoutRow(layout, before), needles[i])
+		complete[i] = ok
+		for r, indexes := range layout {
+			if starts[r] < 0 {
+				continue
+			}
+			if first[i] < 0 {
+				first[i] = starts[r]
+			}
+			last[i] = ends[r]
+			for row := starts[r]; row <= ends[r]; row++ {
+				for _, index := range indexes {
+					res[row] = append(res[row], patch.Line{Hunk: i, Index: index})
+				}
+			}
+		}
+	}
+
+	// The rows between the hunks around one that couldn't be placed whole may
+	// show its lines.
+	for i := range complete {
+		if complete[i] {
+			continue
+		}
+		lo, hi := 0, len(rows)-1
+		for j := i - 1; j >= 0; j-- {
+			if last[j] >= 0 {
+				lo = last[j] + 1
+				break
+			}
+		}
+		for j := i + 1; j < len(first); j++ {
+			if first[j] >= 0 {
+				hi = first[j] - 1
+				break
+			}
+		}
+		for row := lo; row <= hi; row++ {
+			unsure[row] = true
+		}
+	}
+	return res, unsure
+}
+
+// placeHunk returns the first and last rows showing each row of the hunk's
+// layout, from the row of its kth row, or -1 for the rows that couldn't be
+// found, in which case it isn't ok.
+func placeHunk(rows []string, frag *gitdiff.TextFragment, layout [][]int, k, row int) ([]int, []int, bool) {
+	starts := make([]int, len(layout))
+	ends := make([]int, len(layout))
+	for r := range layout {
+		starts[r], ends[r] = -1, -1
+	}
+	wraps := func(row int) bool {
+		return row >= 0 && row < len(rows) && strings.ContainsAny(rows[row], wrapSymbols)
+	}
+	end := func(start int) int {
+		for start+1 < len(rows) && wraps(start) {
+			start++
+		}
+		return start
+	}
+
+	starts[k], ends[k] = row, end(row)
+	for r := k + 1; r < len(layout); r++ {
+		next := -1
+		for c := ends[r-1] + 1; c <= ends[r-1]+1+maxWrappedRows && c < len(rows); c++ {
+			if showsLines(rows[c], frag, layout[r]) {
+				next = c
+				break
+			}
+		}
+		if next < 0 {
+			return starts, ends, false
+		}
+		// Rows skipped on the way wrap the previous line.
+		ends[r-1] = next - 1
+		starts[r], ends[r] = next, end(next)
+	}
+	for r := k - 1; r >= 0; r-- {
+		e := starts[r+1] - 1
+		lowest := max(0, e-maxWrappedRows)
+		// The line starts on the first of the rows it wraps on.
+		start := e
+		for start > lowest && wraps(start-1) {
+			start--
+		}
+		for start >= lowest && !showsLines(rows[start], frag, layout[r]) {
+			start--
+		}
+		if start < lowest {
+			return starts, ends, false
+		}
+		starts[r], ends[r] = start, e
+	}
+	return starts, ends, true
+}
+
+// showsLines reports whether the row, stripped of styling and whitespace,
+// shows the start of each of the lines of the hunk at the given indexes.
+func showsLines(row string, frag *gitdiff.TextFragment, indexes []int) bool {
+	for _, i := range indexes {
+		text := []rune(stripSpaces(frag.Lines[i].Line))
+		if len(text) > lineMatchLen {
+			text = text[:lineMatchLen]
+		}
+		if !strings.Contains(row, string(text)) {
+			return false
+		}
+	}
+	return true
+}
+
+// hunkLayout returns the indexes of the lines of the hunk shown by each of
+// its rows.
+func hunkLayout(frag *gitdiff.TextFragment, sideBySide bool) [][]int {
+	var rows [][]int
+	lines := frag.Lines
+	for i := 0; i < len(lines); {
+		if !sideBySide || lines[i].Op == gitdiff.OpContext {
+			rows = append(rows, []int{i})
+			i++
+			continue
+		}
+		// A run of removed lines, then one of added lines, side by side.
+		removed := i
+		for i < len(lines) && lines[i].Op == gitdiff.OpDelete {
+			i++
+		}
+		added := i
+		for i < len(lines) && lines[i].Op == gitdiff.OpAdd {
+			i++
+		}
+		for r := 0; r < max(added-removed, i-added); r++ {
+			var row []int
+			if removed+r < added {
+				row = append(row, removed+r)
+			}
+			if added+r < i {
+				row = append(row, added+r)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// layoutRow returns the row of the hunk's layout showing the line at the
+// given index.
+func layoutRow(layout [][]int, index int) int {
+	for r, indexes := range layout {
+		if slices.Contains(indexes, index) {
+			return r
+		}
+	}
+	return 0
+}
@@ -3,6 +3,7 @@ package filetree
 import (
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
@@ -14,18 +15,45 @@ import (
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
 	"github.com/charmbracelet/log"
 
+	"github.com/dlvhdr/diffnav/pkg/codeowners"
 	"github.com/dlvhdr/diffnav/pkg/config"
 	"github.com/dlvhdr/diffnav/pkg/constants"
 	"github.com/dlvhdr/diffnav/pkg/dirnode"
 	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/hunknode"
+	"github.com/dlvhdr/diffnav/pkg/theme"
 	"github.com/dlvhdr/diffnav/pkg/ui/common"
 	"github.com/dlvhdr/diffnav/pkg/utils"
 )
 
+// File tree mode constants.
+const (
+	ModeTree = "tree"
+	ModeFlat = "flat"
+)
+
+// GeneratedGroupPath is the path of the group holding collapsed files.
+const GeneratedGroupPath = "[generated]"
+
 type Model struct {
 	t     tree.Model
 	files []*gitdiff.File
 	cfg   config.Config
+	// hiddenStatuses holds the statuses filtered out of the tree.
+	hiddenStatuses map[filenode.Status]bool
+	// collapsed holds the paths of files shown in the "generated" group.
+	collapsed     map[string]bool
+	generatedOpen bool
+	// viewed holds the paths of files marked as viewed.
+	viewed map[string]bool
+	// owners holds the CODEOWNERS owners of each file.
+	owners       map[string][]string
+	groupByOwner bool
+	// expanded holds the paths of files whose hunks are listed below them.
+	expanded map[string]bool
+	// selected holds the paths of files marked for the combined diff.
+	selected map[string]bool
+	keys     KeyMap
 }
 
 func New(cfg config.Config) Model {
@@ -36,9 +64,14 @@ func New(cfg config.Config) Model {
 	t.SetScrollOff(3)
 
 	m := Model{
-		t:   t,
-		cfg: cfg,
+		t:        t,
+		cfg:      cfg,
+		viewed:   map[string]bool{},
+		expanded: map[string]bool{},
+		selected: map[string]bool{},
+		keys:     DefaultKeyMap(),
 	}
+	m.setHiddenStatuses(cfg.UI.HiddenStatuses)
 
 	open, closed := getDirIcons(m.cfg.UI.Icons)
 	t.SetOpenCharacter(open)
@@ -48,21 +81,123 @@ func New(cfg config.Config) Model {
 	return m
 }
 
+// setHiddenStatuses hides the files with the given statuses, by name.
+func (m *Model) setHiddenStatuses(names []string) {
+	m.hiddenStatuses = map[filenode.Status]bool{}
+	for _, name := range names {
+		if status, ok := filenode.ParseStatus(name); ok {
+			m.hiddenStatuses[status] = true
+		}
+	}
+}
+
+// SetConfig applies a reloaded config, rebuilding the tree with its icons,
+// colors and mode while keeping the cursor on the same node. The statuses
+// hidden with the filter bar are only reset when the config changes them.
+func (m *Model) SetConfig(cfg config.Config) {
+	if !slices.Equal(cfg.UI.HiddenStatuses, m.cfg.UI.HiddenStatuses) {
+		m.setHiddenStatuses(cfg.UI.HiddenStatuses)
+	}
+	m.cfg = cfg
+	m.updateStyles()
+	if len(m.files) == 0 {
+		return
+	}
+	m.rebuildAt(m.CurrNodePath())
+}
+
+// SetKeyMap replaces the bindings used to expand and collapse nodes.
+func (m *Model) SetKeyMap(keys KeyMap) {
+	m.keys = keys
+}
+
 func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
 		switch {
-		case key.Matches(msg, keys.ExpandNode):
-			m.t.OpenCurrentNode()
+		case key.Matches(msg, m.keys.ExpandNode):
+			m.ExpandNode()
+		case key.Matches(msg, m.keys.CollapseNode):
+			m.CollapseNode()
+		case key.Matches(msg, m.keys.ToggleNode):
+			m.ToggleNode()
+		}
+	}
+	return m, nil
+}
+
+// ExpandNode opens the directory under the cursor. Files expand into their
+// hunks rather than opening like directories.
+func (m *Model) ExpandNode() {
+	if file, ok := m.currValue().(*filenode.FileNode); ok {
+		m.setExpanded(file.Path(), true)
+		return
+	}
+	m.t.OpenCurrentNode()
+	m.rememberGenerated()
+}
+
+// CollapseNode closes the directory under the cursor, or the hunks of the file
+// under it. On a hunk, it collapses the file and moves the cursor back to it.
+func (m *Model) CollapseNode() {
+	switch value := m.currValue().(type) {
+	case *filenode.FileNode:
+		m.setExpanded(value.Path(), false)
+	case *hunknode.HunkNode:
+		path := value.Path()
+		delete(m.expanded, path)
+		m.rebuildAt(path)
+	default:
+		m.t.CloseCurrentNode()
+		m.rememberGenerated()
+	}
+}
+
+// ToggleNode opens or closes the directory under the cursor, or the hunks of
+// the file under it.
+func (m *Model) ToggleNode() {
+	if file, ok := m.currValue().(*filenode.FileNode); ok {
+		m.setExpanded(file.Path(), !m.expanded[file.Path()])
+		return
+	}
+	m.t.ToggleCurrentNode()
+	m.rememberGenerated()
+}
 
-		case key.Matches(msg, keys.CollapseNode):
-			m.t.CloseCurrentNode()
+// currValue returns the value of the node under the cursor, or nil.
+func (m *Model) currValue() any {
+	node := m.t.NodeAtCurrentOffset()
+	if node == nil {
+		return nil
+	}
+	return node.GivenValue()
+}
 
-		case key.Matches(msg, keys.ToggleNode):
-			m.t.ToggleCurrentNode()
+// rememberGenerated remembers the state of the generated group as the tree is
+// rebuilt often.
+func (m *Model) rememberGenerated() {
+	if node := m.t.NodeAtCurrentOffset(); node != nil {
+		if dir, ok := node.GivenValue().(*dirnode.DirNode); ok && dir.Generated {
+			m.generatedOpen = node.IsOpen()
 		}
 	}
-	return m, nil
+}
+
+func (m *Model) setExpanded(path string, expanded bool) {
+	if m.expanded[path] == expanded {
+		return
+	}
+	if expanded {
+		m.expanded[path] = true
+	} else {
+		delete(m.expanded, path)
+	}
+	m.Refresh()
+}
+
+// IsExpanded returns whether the hunks of the file are listed in the tree.
+func (m *Model) IsExpanded(path string) bool {
+	return m.expanded[path]
 }
 
 func (m *Model) View() string {
@@ -84,12 +219,13 @@ func getDirIcons(iconStyle string) (string, string) {
 }
 
 func (m *Model) updateStyles() {
-	dimmed := common.Colors[common.Selected]
+	t := theme.Current()
+	dimmed := t.Selection
 	base := lipgloss.NewStyle()
 	m.t.SetStyles(tree.Styles{
 		TreeStyle:       base,
-		RootNodeStyle:   base.Foreground(lipgloss.BrightBlue),
-		ParentNodeStyle: base.Foreground(lipgloss.BrightBlue),
+		RootNodeStyle:   base.Foreground(t.Directory),
+		ParentNodeStyle: base.Foreground(t.Directory),
 		SelectedNodeStyleFunc: func(children tree.Nodes, i int) lipgloss.Style {
 			base := base.Bold(true).Background(dimmed)
 			child := children.At(i)
@@ -97,13 +233,13 @@ func (m *Model) updateStyles() {
 			case *filenode.FileNode:
 				return base
 			case string, *dirnode.DirNode:
-				return base.Foreground(lipgloss.BrightBlue)
+				return base.Foreground(t.Directory)
 			}
 			return base
 		},
 		HelpStyle:               base.MarginTop(1),
 		EnumeratorStyle:         base.Foreground(dimmed),
-		SelectedEnumeratorStyle: base.Bold(true).Foreground(lipgloss.BrightBlue),
+		SelectedEnumeratorStyle: base.Bold(true).Foreground(t.Directory),
 		IndenterStyle:           base.Foreground(dimmed),
 	})
 
@@ -112,6 +248,12 @@ func (m *Model) updateStyles() {
 	m.t.SetClosedCharacter(closed)
 }
 
+// SetCollapsed sets the paths of the files that are moved into the collapsed
+// "generated" group. It takes effect on the next SetFiles.
+func (m *Model) SetCollapsed(paths map[string]bool) {
+	m.collapsed = paths
+}
+
 func (m Model) SetFiles(files []*gitdiff.File) Model {
 	m.files = files
 	m.rebuildTree()
@@ -131,6 +273,52 @@ func (m *Model) Up() {
 	m.t.Up()
 }
 
+// MoveCursor moves the cursor by the given number of nodes, stopping at the
+// first and last ones.
+func (m *Model) MoveCursor(movement int) {
+	m.t.SetYOffset(m.t.YOffset() + movement)
+}
+
+// Cursor returns the row of the cursor and the row at the top of the tree.
+func (m *Model) Cursor() (int, int) {
+	return m.t.YOffset(), m.t.ViewportYOffset()
+}
+
+// SetCursor moves the cursor to a row and scrolls the tree to another, as
+// returned by Cursor.
+func (m *Model) SetCursor(cursor, scroll int) {
+	m.t.SetYOffset(cursor)
+	m.t.SetViewportYOffset(scroll)
+}
+
+// CenterCursor scrolls the tree so the cursor is in the middle of it.
+func (m *Model) CenterCursor() {
+	m.t.SetViewportYOffset(m.t.YOffset() - m.t.Height()/2)
+}
+
+// GoToFile moves the cursor to the nth file NextFile may stop at, counting
+// from 1, or to the last one if there are fewer. It reports whether there
+// was a file to move to.
+func (m *Model) GoToFile(n int) bool {
+	if len(m.files) == 0 {
+		return false
+	}
+	offset := -1
+	for _, node := range m.t.AllNodes() {
+		if file, ok := node.GivenValue().(*filenode.FileNode); ok && m.isNavigable(file) {
+			offset = node.YOffset()
+			if n--; n <= 0 {
+				break
+			}
+		}
+	}
+	if offset < 0 {
+		return false
+	}
+	m.t.SetYOffset(offset)
+	return true
+}
+
 // NextFile moves the cursor to the next file node, skipping directories.
 func (m *Model) NextFile() bool {
 	curr := m.t.NodeAtCurrentOffset()
@@ -146,7 +334,7 @@ func (m *Model) NextFile() bool {
 			}
 			continue
 		}
-		if _, ok := node.GivenValue().(*filenode.FileNode); ok {
+		if file, ok := node.GivenValue().(*filenode.FileNode); ok && m.isNavigable(file) {
 			m.t.SetYOffset(node.YOffset())
 			return true
 		}
@@ -166,7 +354,7 @@ func (m *Model) PrevFile() bool {
 		if node.YOffset() >= curr.YOffset() {
 			break
 		}
-		if _, ok := node.GivenValue().(*filenode.FileNode); ok {
+		if file, ok := node.GivenValue().(*filenode.FileNode); ok && m.isNavigable(file) {
 			lastFileOffset = node.YOffset()
 		}
 	}
@@ -177,36 +365,108 @@ func (m *Model) PrevFile() bool {
 	return false
 }
 
+// isNavigable returns whether NextFile and PrevFile may stop at the file.
+func (m *Model) isNavigable(file *filenode.FileNode) bool {
+	if m.collapsed[file.Path()] {
+		return false
+	}
+	return !m.cfg.UI.SkipViewed || !m.viewed[file.Path()]
+}
+
+// SetCursorByPath moves the cursor to the file or directory at the path,
+// opening the directories it is in. The tree isn't rebuilt, so directories
+// the user closed stay closed.
 func (m *Model) SetCursorByPath(path string) {
 	if len(m.files) == 0 {
 		return
 	}
 
-	yoffset := 0
-	for _, node := range m.t.AllNodes() {
-		switch val := node.GivenValue().(type) {
-		case *filenode.FileNode:
-			name := filenode.GetFileName(val.File)
-			if name == path {
-				yoffset = node.YOffset()
-				break
-			}
-		case *dirnode.DirNode:
-			if val.FullPath == path {
-				yoffset = node.YOffset()
-				break
+	nodes, opened := findPath(m.t.Root(), path)
+	if nodes == nil {
+		m.t.SetYOffset(0)
+		return
+	}
+	if opened {
+		for _, node := range nodes {
+			if dir, ok := node.GivenValue().(*dirnode.DirNode); ok && dir.Generated {
+				m.generatedOpen = true
 			}
 		}
+		m.t.SetNodes(m.t.Root())
 	}
+	m.t.SetYOffset(nodes[len(nodes)-1].YOffset())
+}
 
+// rebuildAt rebuilds the tree, e.g. after switching modes, and moves the
+// cursor back to the node at the path.
+func (m *Model) rebuildAt(path string) {
 	m.rebuildTree()
-	m.t.SetYOffset(yoffset)
+	m.SetCursorByPath(path)
+}
+
+// findPath returns the nodes from the root down to the first file or
+// directory at the path, or nil when there is none. Closed directories are
+// opened to look into them, and stay open when they hold the path.
+func findPath(node *tree.Node, path string) ([]*tree.Node, bool) {
+	closed := false
+	switch val := node.GivenValue().(type) {
+	case *filenode.FileNode:
+		if filenode.GetFileName(val.File) == path {
+			return []*tree.Node{node}, false
+		}
+	case *dirnode.DirNode:
+		if val.FullPath == path {
+			return []*tree.Node{node}, false
+		}
+		closed = !node.IsOpen()
+	}
+	if closed {
+		node.Open()
+	}
+	for _, child := range node.ChildNodes() {
+		if nodes, opened := findPath(child, path); nodes != nil {
+			return append([]*tree.Node{node}, nodes...), opened || closed
+		}
+	}
+	if closed {
+		node.Close()
+	}
+	return nil, false
 }
 
 func (m *Model) rebuildTree() {
-	t := buildFullFileTree(m.files, m.cfg)
-	t = collapseTree(t)
+	var t *tree.Node
+	files, generated := m.splitCollapsed(m.VisibleFiles())
+	switch {
+	case m.groupByOwner:
+		t = buildOwnerTree(files, m.owners, m.Mode(), m.cfg)
+	case m.cfg.UI.FileTreeMode == ModeFlat:
+		t = buildFlatFileTree(files, m.cfg)
+	default:
+		t = buildFullFileTree(files, m.cfg)
+		t = collapseTree(t)
+	}
+	if len(generated) > 0 {
+		t.Child(buildGeneratedGroup(generated, m.cfg))
+	}
+	setDirStats(t, m.cfg)
+	if len(m.expanded) > 0 {
+		t = expandHunks(t, m.expanded, m.cfg)
+	}
+	for _, node := range t.AllNodes() {
+		if file, ok := node.GivenValue().(*filenode.FileNode); ok {
+			file.Viewed = m.viewed[file.Path()]
+			file.Marked = m.selected[file.Path()]
+		}
+	}
 	t, _ = truncateTree(t, 0, 0, 0, m.cfg, m.t.Width())
+	if !m.generatedOpen {
+		for _, child := range t.ChildNodes() {
+			if dir, ok := child.GivenValue().(*dirnode.DirNode); ok && dir.Generated {
+				child.Close()
+			}
+		}
+	}
 	m.t.SetNodes(t)
 	m.t.SetWidth(m.t.Width())
 	m.updateStyles()
@@ -267,6 +527,166 @@ func buildFullFileTree(files []*gitdiff.File, cfg config.Config) *tree.Node {
 	return t
 }
 
+// buildFlatFileTree lists every file directly under the root, showing its full path.
+func buildFlatFileTree(files []*gitdiff.File, cfg config.Config) *tree.Node {
+	t := tree.Root(&dirnode.DirNode{FullPath: "/", Name: constants.RootName})
+	for _, file := range files {
+		t.Child(&filenode.FileNode{
+			File:         file,
+			Cfg:          cfg,
+			ShowFullPath: true,
+		})
+	}
+	return t
+}
+
+// buildOwnerTree splits the files into one group per set of owners, each
+// holding a tree or a flat list of its files. Files without owners come last.
+func buildOwnerTree(
+	files []*gitdiff.File,
+	owners map[string][]string,
+	mode string,
+	cfg config.Config,
+) *tree.Node {
+	groups := map[string][]*gitdiff.File{}
+	for _, file := range files {
+		group := codeowners.Group(owners[filenode.GetFileName(file)])
+		groups[group] = append(groups[group], file)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		if name != codeowners.Unowned {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	if _, ok := groups[codeowners.Unowned]; ok {
+		names = append(names, codeowners.Unowned)
+	}
+
+	t := tree.Root(&dirnode.DirNode{FullPath: "/", Name: constants.RootName})
+	for _, name := range names {
+		var sub *tree.Node
+		if mode == ModeFlat {
+			sub = buildFlatFileTree(groups[name], cfg)
+		} else {
+			sub = collapseTree(buildFullFileTree(groups[name], cfg))
+		}
+		for _, node := range sub.AllNodes() {
+			if dir, ok := node.GivenValue().(*dirnode.DirNode); ok {
+				dir.Group = name
+			}
+		}
+
+		children := make([]any, 0)
+		for _, c := range sub.ChildNodes() {
+			children = append(children, c)
+		}
+		t.Child(tree.Root(&dirnode.DirNode{FullPath: name, Name: name}).Child(children...))
+	}
+	return t
+}
+
+// buildGeneratedGroup lists the collapsed files with their full paths under
+// a single "generated" directory node.
+func buildGeneratedGroup(files []*gitdiff.File, cfg config.Config) *tree.Node {
+	group := tree.Root(&dirnode.DirNode{
+		FullPath:  GeneratedGroupPath,
+		Name:      "generated",
+		Generated: true,
+	})
+	for _, file := range files {
+		group.Child(&filenode.FileNode{
+			File:         file,
+			Cfg:          cfg,
+			ShowFullPath: true,
+		})
+	}
+	return group
+}
+
+// splitCollapsed separates the files shown in the generated group from the rest.
+func (m *Model) splitCollapsed(files []*gitdiff.File) ([]*gitdiff.File, []*gitdiff.File) {
+	if len(m.collapsed) == 0 {
+		return files, nil
+	}
+	var rest, collapsed []*gitdiff.File
+	for _, file := range files {
+		if m.collapsed[filenode.GetFileName(file)] {
+			collapsed = append(collapsed, file)
+		} else {
+			rest = append(rest, file)
+		}
+	}
+	return rest, collapsed
+}
+
+// setDirStats sums up the stats of the files below every directory node.
+func setDirStats(t *tree.Node, cfg config.Config) {
+	added, deleted, _ := aggregateDirStats(t, cfg)
+	for _, node := range t.AllNodes() {
+		if dir, ok := node.GivenValue().(*dirnode.DirNode); ok {
+			dir.TotalChurn = added + deleted
+		}
+	}
+}
+
+func aggregateDirStats(t *tree.Node, cfg config.Config) (int64, int64, int) {
+	var added, deleted int64
+	var files int
+	for _, child := range t.ChildNodes() {
+		switch value := child.GivenValue().(type) {
+		case *filenode.FileNode:
+			a, d := filenode.DiffStats(value.File)
+			added += a
+			deleted += d
+			files++
+		case *dirnode.DirNode:
+			a, d, f := aggregateDirStats(child, cfg)
+			added += a
+			deleted += d
+			files += f
+		}
+	}
+
+	if dir, ok := t.GivenValue().(*dirnode.DirNode); ok {
+		dir.Additions = added
+		dir.Deletions = deleted
+		dir.Files = files
+		dir.Cfg = cfg
+	}
+	return added, deleted, files
+}
+
+// expandHunks lists the hunks of every expanded file right below it.
+func expandHunks(t *tree.Node, expanded map[string]bool, cfg config.Config) *tree.Node {
+	newT := tree.Root(t.GivenValue())
+	for _, child := range t.ChildNodes() {
+		switch value := child.GivenValue().(type) {
+		case *dirnode.DirNode:
+			newT.Child(expandHunks(child, expanded, cfg))
+		case *filenode.FileNode:
+			newT.Child(value)
+			if !expanded[value.Path()] {
+				continue
+			}
+			for i, frag := range value.File.TextFragments {
+				newT.Child(&hunknode.HunkNode{
+					File:     value.File,
+					Fragment: frag,
+					Index:    i,
+					Cfg:      cfg,
+				})
+			}
+		default:
+			newT.Child(child)
+		}
+	}
+	return newT
+}
+
 // Given a tree with nodes that have only one child, collapse the tree by
 // merging these nodes with their parents, as long as the parent has only one child as well.
 // For example, the tree:
@@ -349,12 +769,9 @@ func truncateTree(
 		return t, 0
 	}
 
-	newT := tree.Root(
-		&dirnode.DirNode{
-			Name:     utils.TruncateString(dir.Name, width-depth-2),
-			FullPath: dir.FullPath,
-		},
-	)
+	truncated := *dir
+	truncated.Name = utils.TruncateString(dir.Name, width-depth-2-dir.StatsWidth())
+	newT := tree.Root(&truncated)
 	numNodes++
 
 	for _, child := range t.ChildNodes() {
@@ -371,6 +788,11 @@ func truncateTree(
 			value.Depth = depth + 1
 			value.PanelWidth = width
 			newT.Child(value)
+		case *hunknode.HunkNode:
+			numNodes++
+			value.Depth = depth + 1
+			value.PanelWidth = width
+			newT.Child(value)
 		}
 	}
 	return newT, numChildren
@@ -409,6 +831,9 @@ func (m *Model) GetCurrNode() *tree.Node {
 }
 
 func (m *Model) GetCurrNodeDesendantDiffs() []*gitdiff.File {
+	if hunk, ok := m.GetCurrNode().GivenValue().(*hunknode.HunkNode); ok {
+		return []*gitdiff.File{hunk.File}
+	}
 	var files []*gitdiff.File
 	for _, node := range m.GetCurrNode().AllNodes() {
 		if file, ok := node.GivenValue().(*filenode.FileNode); ok {
@@ -425,8 +850,6 @@ func (m *Model) SetCursorNoScroll(cursor int) {
 		return
 	}
 	scroll := m.t.ViewportYOffset()
-	m.rebuildTree()
-
 	m.t.SetYOffset(cursor)
 	m.t.SetViewportYOffset(scroll)
 }
@@ -436,6 +859,8 @@ func (m *Model) CurrNodePath() string {
 	switch val := m.t.NodeAtCurrentOffset().GivenValue().(type) {
 	case *filenode.FileNode:
 		fullpath = filenode.GetFileName(val.File)
+	case *hunknode.HunkNode:
+		fullpath = val.Path()
 	case *dirnode.DirNode:
 		fullpath = val.FullPath
 	}
@@ -453,6 +878,22 @@ func (m *Model) CopyCurrNodePath() tea.Cmd {
 	return nil
 }
 
+// CopySelectedPaths copies the paths of the selected files, one per line.
+func (m *Model) CopySelectedPaths() tea.Cmd {
+	files := m.SelectedFiles()
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, filenode.GetFileName(file))
+	}
+	err := clipboard.WriteAll(strings.Join(paths, "\n"))
+	if err != nil {
+		return func() tea.Msg {
+			return common.ErrMsg{Err: err}
+		}
+	}
+	return nil
+}
+
 // ScrollUp scrolls the viewport up by the given number of lines.
 func (m *Model) ScrollUp(lines int) {
 	newOffset := m.t.ViewportYOffset() - lines
@@ -465,6 +906,160 @@ func (m *Model) ScrollDown(lines int) {
 	m.t.SetViewportYOffset(newOffset)
 }
 
+// VisibleFiles returns the files that are not hidden by the status filter.
+func (m *Model) VisibleFiles() []*gitdiff.File {
+	if !m.HasStatusFilter() {
+		return m.files
+	}
+	files := make([]*gitdiff.File, 0, len(m.files))
+	for _, file := range m.files {
+		if !m.hiddenStatuses[filenode.GetFileStatus(file)] {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// HasStatusFilter returns whether any status is currently hidden.
+func (m *Model) HasStatusFilter() bool {
+	for _, hidden := range m.hiddenStatuses {
+		if hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStatusHidden returns whether files with the given status are hidden.
+func (m *Model) IsStatusHidden(status filenode.Status) bool {
+	return m.hiddenStatuses[status]
+}
+
+// ToggleStatus shows or hides the files with the given status and rebuilds
+// the tree, keeping the cursor on the same node when it is still visible.
+func (m *Model) ToggleStatus(status filenode.Status) {
+	m.hiddenStatuses[status] = !m.hiddenStatuses[status]
+	if len(m.files) == 0 {
+		return
+	}
+	m.rebuildAt(m.CurrNodePath())
+}
+
+// SetViewed marks or un-marks the file as viewed. Call Refresh to re-render.
+func (m *Model) SetViewed(path string, viewed bool) {
+	if viewed {
+		m.viewed[path] = true
+	} else {
+		delete(m.viewed, path)
+	}
+}
+
+// IsViewed returns whether the file is marked as viewed.
+func (m *Model) IsViewed(path string) bool {
+	return m.viewed[path]
+}
+
+// ToggleSelected marks the files under the cursor as selected, or un-marks
+// them when they are all selected already.
+func (m *Model) ToggleSelected() {
+	if len(m.files) == 0 {
+		return
+	}
+	files := m.GetCurrNodeDesendantDiffs()
+	mark := false
+	for _, file := range files {
+		if !m.selected[filenode.GetFileName(file)] {
+			mark = true
+			break
+		}
+	}
+	for _, file := range files {
+		if mark {
+			m.selected[filenode.GetFileName(file)] = true
+		} else {
+			delete(m.selected, filenode.GetFileName(file))
+		}
+	}
+	m.Refresh()
+}
+
+// ClearSelection un-marks all selected files.
+func (m *Model) ClearSelection() {
+	if len(m.selected) == 0 {
+		return
+	}
+	m.selected = map[string]bool{}
+	if len(m.files) > 0 {
+		m.Refresh()
+	}
+}
+
+// SelectedFiles returns the visible selected files in file order.
+func (m *Model) SelectedFiles() []*gitdiff.File {
+	if len(m.selected) == 0 {
+		return nil
+	}
+	var files []*gitdiff.File
+	for _, file := range m.VisibleFiles() {
+		if m.selected[filenode.GetFileName(file)] {
+			files = append(files, file)
+		}
+	}
+	return files
+}
+
+// Refresh rebuilds the tree keeping the cursor and the scroll position.
+func (m *Model) Refresh() {
+	if len(m.files) == 0 {
+		return
+	}
+	cursor, scroll := m.Cursor()
+	m.rebuildTree()
+	m.SetCursor(cursor, scroll)
+}
+
+// Mode returns the current file tree mode, either ModeTree or ModeFlat.
+func (m *Model) Mode() string {
+	if m.cfg.UI.FileTreeMode == ModeFlat {
+		return ModeFlat
+	}
+	return ModeTree
+}
+
+// ToggleMode switches between the nested tree and the flat list, keeping the
+// cursor on the same file when possible.
+func (m *Model) ToggleMode() {
+	if m.Mode() == ModeFlat {
+		m.cfg.UI.FileTreeMode = ModeTree
+	} else {
+		m.cfg.UI.FileTreeMode = ModeFlat
+	}
+	if len(m.files) == 0 {
+		return
+	}
+	m.rebuildAt(m.CurrNodePath())
+}
+
+// SetOwners sets the owners of each file, used when grouping by owner.
+func (m *Model) SetOwners(owners map[string][]string) {
+	m.owners = owners
+}
+
+// GroupedByOwner returns whether the tree is split into owner groups.
+func (m *Model) GroupedByOwner() bool {
+	return m.groupByOwner
+}
+
+// ToggleOwnerGroups switches between grouping the files by owner and the
+// regular tree, keeping the cursor on the same file when possible.
+func (m *Model) ToggleOwnerGroups() {
+	m.groupByOwner = !m.groupByOwner
+	if len(m.files) == 0 {
+		return
+	}
+	m.rebuildAt(m.CurrNodePath())
+}
+
 // SetIconStyle changes the icon style and regenerates the tree.
 func (m *Model) SetIconStyle(iconStyle string) {
 	m.cfg.UI.Icons = iconStyle
@@ -4,11 +4,14 @@ import (
 	"os"
 	"testing"
 
+	tea "charm.land/bubbletea/v2"
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/dlvhdr/diffnav/pkg/codeowners"
 	"github.com/dlvhdr/diffnav/pkg/config"
 	"github.com/dlvhdr/diffnav/pkg/constants"
 	"github.com/dlvhdr/diffnav/pkg/dirnode"
 	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/hunknode"
 )
 
 // .
@@ -185,3 +188,294 @@ func TestUncollapsableTree(t *testing.T) {
 		t.Fatalf("expected 13 nodes, but got %d", len(allNodes))
 	}
 }
+
+// output:
+// .
+// ├── graphql-server/tests/package.json
+// └── yarn.lock
+func TestBuildFlatFileTree(t *testing.T) {
+	f, err := os.Open("testdata/multiple_files.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := buildFlatFileTree(files, config.Config{})
+	children := tr.ChildNodes()
+	if len(children) != 2 {
+		t.Fatalf("expected root to have 2 children, but got %d", len(children))
+	}
+
+	packageJson, ok := children[0].GivenValue().(*filenode.FileNode)
+	if !ok {
+		t.Fatalf("expected root first child to be a file, but got %T", children[0].GivenValue())
+	}
+	if !packageJson.ShowFullPath {
+		t.Fatal("expected files in the flat list to show their full path")
+	}
+	if packageJson.Path() != "graphql-server/tests/package.json" {
+		t.Fatalf(
+			`expected root first child value to be "graphql-server/tests/package.json", but got %s`,
+			packageJson.Path(),
+		)
+	}
+}
+
+func TestGeneratedGroupIsSkippedByNextFile(t *testing.T) {
+	f, err := os.Open("testdata/multiple_files.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(config.DefaultConfig())
+	m.SetCollapsed(map[string]bool{"yarn.lock": true})
+	m = m.SetFiles(files)
+
+	children := m.t.Root().ChildNodes()
+	group, ok := children[len(children)-1].GivenValue().(*dirnode.DirNode)
+	if !ok || !group.Generated {
+		t.Fatalf("expected the last root child to be the generated group, got %v", children[len(children)-1].GivenValue())
+	}
+
+	if !m.NextFile() {
+		t.Fatal("expected to move to the first file")
+	}
+	if path := m.CurrNodePath(); path != "graphql-server/tests/package.json" {
+		t.Fatalf(`expected cursor on "graphql-server/tests/package.json", got %q`, path)
+	}
+	if m.NextFile() {
+		t.Fatalf("expected NextFile to skip the generated group, moved to %q", m.CurrNodePath())
+	}
+}
+
+func TestSetDirStats(t *testing.T) {
+	f, err := os.Open("testdata/gh_dash_pr.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tr := collapseTree(buildFullFileTree(files, config.Config{}))
+	setDirStats(tr, config.Config{})
+
+	var added, deleted int64
+	for _, file := range files {
+		a, d := filenode.DiffStats(file)
+		added += a
+		deleted += d
+	}
+
+	root := tr.GivenValue().(*dirnode.DirNode)
+	if root.Files != len(files) {
+		t.Fatalf("expected root to count %d files, got %d", len(files), root.Files)
+	}
+	if root.Additions != added || root.Deletions != deleted {
+		t.Fatalf("expected root stats +%d -%d, got +%d -%d",
+			added, deleted, root.Additions, root.Deletions)
+	}
+	if root.TotalChurn != added+deleted {
+		t.Fatalf("expected total churn %d, got %d", added+deleted, root.TotalChurn)
+	}
+
+	ui := tr.ChildNodes()[0].GivenValue().(*dirnode.DirNode)
+	if ui.Files != len(files) || ui.Additions != added {
+		t.Fatalf("expected ui to hold all %d files, got %d", len(files), ui.Files)
+	}
+}
+
+func TestBuildOwnerTree(t *testing.T) {
+	f, err := os.Open("testdata/multiple_files.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	owners := map[string][]string{
+		"graphql-server/tests/package.json": {"@org/server"},
+	}
+	tr := buildOwnerTree(files, owners, ModeTree, config.Config{})
+	children := tr.ChildNodes()
+	if len(children) != 2 {
+		t.Fatalf("expected root to have 2 owner groups, but got %d", len(children))
+	}
+
+	server := children[0].GivenValue().(*dirnode.DirNode)
+	if server.Name != "@org/server" {
+		t.Fatalf(`expected first group to be "@org/server", got %q`, server.Name)
+	}
+	dir, ok := children[0].ChildNodes()[0].GivenValue().(*dirnode.DirNode)
+	if !ok {
+		t.Fatalf("expected the group to hold a directory, got %T", children[0].ChildNodes()[0].GivenValue())
+	}
+	if dir.DiffPath() != "@org/server: graphql-server/tests" {
+		t.Fatalf(`expected the directory diff path to include its group, got %q`, dir.DiffPath())
+	}
+
+	unowned := children[1].GivenValue().(*dirnode.DirNode)
+	if unowned.Name != codeowners.Unowned {
+		t.Fatalf("expected files without owners to come last, got %q", unowned.Name)
+	}
+	if file, ok := children[1].ChildNodes()[0].GivenValue().(*filenode.FileNode); !ok || file.Path() != "yarn.lock" {
+		t.Fatalf(`expected "yarn.lock" in the unowned group, got %v`, children[1].ChildNodes()[0].GivenValue())
+	}
+}
+
+func TestExpandFileIntoHunks(t *testing.T) {
+	f, err := os.Open("testdata/gh_dash_pr.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(config.DefaultConfig())
+	m.SetSize(40, 30)
+	m = m.SetFiles(files)
+	if !m.NextFile() {
+		t.Fatal("expected to move to the first file")
+	}
+	file := m.GetCurrNode().GivenValue().(*filenode.FileNode)
+
+	m.Update(tea.KeyPressMsg(tea.Key{Text: "l", Code: 'l'}))
+	if !m.IsExpanded(file.Path()) {
+		t.Fatal("expected l to expand the file")
+	}
+	var hunks []*hunknode.HunkNode
+	for _, node := range m.t.AllNodes() {
+		if hunk, ok := node.GivenValue().(*hunknode.HunkNode); ok {
+			hunks = append(hunks, hunk)
+		}
+	}
+	if len(hunks) != len(file.File.TextFragments) {
+		t.Fatalf("expected %d hunks, got %d", len(file.File.TextFragments), len(hunks))
+	}
+	if hunks[1].Label() != "func (m *Model) fastForward() (tea.Cmd, error) {" {
+		t.Fatalf("expected the hunk to be labelled with its function, got %q", hunks[1].Label())
+	}
+	if hunks[1].LineRange() != "L77-125" {
+		t.Fatalf("expected the hunk to cover L77-125, got %q", hunks[1].LineRange())
+	}
+
+	m.Down()
+	if _, ok := m.GetCurrNode().GivenValue().(*hunknode.HunkNode); !ok {
+		t.Fatalf("expected the cursor on a hunk, got %T", m.GetCurrNode().GivenValue())
+	}
+	if m.NextFile() && m.CurrNodePath() == file.Path() {
+		t.Fatal("expected NextFile to skip the hunks")
+	}
+	m.PrevFile()
+	m.Down()
+
+	m.Update(tea.KeyPressMsg(tea.Key{Text: "h", Code: 'h'}))
+	if m.IsExpanded(file.Path()) {
+		t.Fatal("expected h on a hunk to collapse its file")
+	}
+	if path := m.CurrNodePath(); path != file.Path() {
+		t.Fatalf("expected the cursor back on %q, got %q", file.Path(), path)
+	}
+}
+
+func TestToggleModeKeepsCursorOnFile(t *testing.T) {
+	f, err := os.Open("testdata/multiple_files.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(config.DefaultConfig())
+	m.SetSize(40, 30)
+	m = m.SetFiles(files)
+	m.NextFile()
+	m.NextFile()
+	path := m.CurrNodePath()
+
+	for _, mode := range []string{ModeFlat, ModeTree} {
+		m.ToggleMode()
+		if m.Mode() != mode {
+			t.Fatalf("expected the %s mode, got %s", mode, m.Mode())
+		}
+		if got := m.CurrNodePath(); got != path {
+			t.Fatalf("%s: expected the cursor to stay on %q, got %q", mode, path, got)
+		}
+	}
+}
+
+func TestSetCursorByPathKeepsClosedDirs(t *testing.T) {
+	f, err := os.Open("testdata/multiple_files.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(config.DefaultConfig())
+	m.SetSize(40, 30)
+	m = m.SetFiles(files)
+	m.SetCursorByPath("graphql-server/tests/package.json")
+	m.Up()
+	dir := m.CurrNodePath()
+	m.CollapseNode()
+
+	m.SetCursorByPath("yarn.lock")
+	m.SetCursorByPath(dir)
+	if m.GetCurrNode().IsOpen() {
+		t.Fatalf("expected %q to stay closed", dir)
+	}
+
+	m.SetCursorByPath("graphql-server/tests/package.json")
+	if got := m.CurrNodePath(); got != "graphql-server/tests/package.json" {
+		t.Fatalf("expected the cursor on the file in the closed directory, got %q", got)
+	}
+}
+
+func TestSetCursorByPathPicksFirstMatch(t *testing.T) {
+	f, err := os.Open("testdata/gh_dash_pr.diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := New(config.DefaultConfig())
+	m.SetSize(40, 30)
+	m = m.SetFiles(files)
+	m.SetOwners(map[string][]string{
+		"ui/components/reposection/commands.go":    {"@org/a"},
+		"ui/components/reposection/reposection.go": {"@org/b"},
+	})
+	m.ToggleOwnerGroups()
+
+	// The directory is listed in both owner groups.
+	m.SetCursorByPath("ui/components/reposection/commands.go")
+	m.Up()
+	dir := m.CurrNodePath()
+	first := m.t.YOffset()
+
+	m.SetCursorByPath("ui/ui.go")
+	m.SetCursorByPath(dir)
+	if got := m.t.YOffset(); got != first {
+		t.Fatalf("expected the cursor on %q in the first group at row %d, got row %d", dir, first, got)
+	}
+}
@@ -8,17 +8,19 @@ type KeyMap struct {
 	ToggleNode   key.Binding
 }
 
-var keys = &KeyMap{
-	ExpandNode: key.NewBinding(
-		key.WithKeys("l"),
-		key.WithHelp("l", "expand"),
-	),
-	CollapseNode: key.NewBinding(
-		key.WithKeys("h"),
-		key.WithHelp("h", "collapse"),
-	),
-	ToggleNode: key.NewBinding(
-		key.WithKeys("enter"),
-		key.WithHelp("enter", "toggle"),
-	),
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		ExpandNode: key.NewBinding(
+			key.WithKeys("l"),
+			key.WithHelp("l", "expand"),
+		),
+		CollapseNode: key.NewBinding(
+			key.WithKeys("h"),
+			key.WithHelp("h", "collapse"),
+		),
+		ToggleNode: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "toggle"),
+		),
+	}
 }
@@ -5,6 +5,8 @@ import (
 	"charm.land/bubbles/v2/key"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/theme"
 )
 
 type Model struct {
@@ -17,16 +19,18 @@ type Model struct {
 func New() Model {
 	m := Model{}
 	m.help = helpBubble.New()
-	helpSt := lipgloss.NewStyle()
 	m.help.ShortSeparator = " · "
-	m.help.Styles.FullKey = helpSt
-	m.help.Styles.FullDesc = helpSt
-	m.help.Styles.FullSeparator = helpSt
-	m.help.Styles.FullKey = helpSt.Foreground(lipgloss.Blue)
+	m.UpdateStyles()
+	return m
+}
+
+// UpdateStyles applies the colors of the current theme.
+func (m *Model) UpdateStyles() {
+	helpSt := lipgloss.NewStyle()
+	m.help.Styles.FullKey = helpSt.Foreground(theme.Current().Accent)
 	m.help.Styles.FullDesc = helpSt
 	m.help.Styles.FullSeparator = helpSt
 	m.help.Styles.Ellipsis = helpSt
-	return m
 }
 
 func (m *Model) Update(msg tea.Msg) tea.Cmd {
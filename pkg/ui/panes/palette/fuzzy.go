@@ -0,0 +1,48 @@
+package palette
+
+import (
+	"strings"
+	"unicode"
+)
+
+// match reports whether the runes of pattern appear in s in order, ignoring
+// case, along with a score and the positions of the matched runes in s. Runes
+// following the previous match, or starting a word, score higher, and
+// earlier matches and shorter strings break ties.
+func match(pattern, s string) (int, []int, bool) {
+	want := []rune(strings.ToLower(pattern))
+	if len(want) == 0 {
+		return 0, nil, true
+	}
+
+	runes := []rune(s)
+	positions := make([]int, 0, len(want))
+	score := 0
+	for i, r := range runes {
+		if len(positions) == len(want) {
+			break
+		}
+		if unicode.ToLower(r) != want[len(positions)] {
+			continue
+		}
+		switch {
+		case len(positions) > 0 && positions[len(positions)-1] == i-1:
+			score += 3
+		case i == 0 || startsWord(runes[i-1], r):
+			score += 2
+		default:
+			score++
+		}
+		positions = append(positions, i)
+	}
+	if len(positions) < len(want) {
+		return 0, nil, false
+	}
+	return score*100 - positions[0] - len(runes), positions, true
+}
+
+// startsWord reports whether r starts a word after prev, in paths, command
+// names and descriptions.
+func startsWord(prev, r rune) bool {
+	return strings.ContainsRune(" /-_.:", prev) || unicode.IsLower(prev) && unicode.IsUpper(r)
+}
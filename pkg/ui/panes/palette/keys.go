@@ -0,0 +1,19 @@
+package palette
+
+import "charm.land/bubbles/v2/key"
+
+// KeyMap holds the bindings moving through the commands and completing the
+// one under the cursor. Other keys go to the input.
+type KeyMap struct {
+	Up       key.Binding
+	Down     key.Binding
+	Complete key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Up:       key.NewBinding(key.WithKeys("up", "ctrl+p")),
+		Down:     key.NewBinding(key.WithKeys("down", "ctrl+n")),
+		Complete: key.NewBinding(key.WithKeys("tab")),
+	}
+}
@@ -0,0 +1,301 @@
+// Package palette implements the command palette, listing the commands with
+// their keys and running them by name, with an argument.
+package palette
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"charm.land/bubbles/v2/key"
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/ui/common"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// Command is a command of the palette.
+type Command struct {
+	Name string
+	Desc string
+	// Key is the key running the command outside the palette, if any.
+	Key string
+	// Values lists the arguments of the command, offered as the argument is
+	// typed. Other arguments are passed on as typed.
+	Values []string
+}
+
+type item struct {
+	command int
+	// value is the argument of the command the item stands for, or "".
+	value string
+	// positions holds the runes of the name, or of the value, matching the
+	// input, and descPositions those of the description.
+	positions     []int
+	descPositions []int
+	score         int
+}
+
+type Model struct {
+	common.Common
+	input    textinput.Model
+	keys     KeyMap
+	commands []Command
+	items    []item
+	cursor   int
+	offset   int
+	err      error
+}
+
+func New() Model {
+	input := textinput.New()
+	input.Prompt = ":"
+	input.Placeholder = "Type a command"
+	return Model{input: input, keys: DefaultKeyMap()}
+}
+
+func (m *Model) SetKeyMap(keys KeyMap) {
+	m.keys = keys
+}
+
+// Open lists commands and focuses the input.
+func (m *Model) Open(commands []Command) tea.Cmd {
+	m.commands = commands
+	m.input.SetValue("")
+	m.input.SetStyles(textinput.Styles{
+		Focused: textinput.StyleState{
+			Placeholder: lipgloss.NewStyle().Foreground(theme.Current().Muted),
+			Prompt:      lipgloss.NewStyle().Foreground(theme.Current().Accent),
+		},
+	})
+	m.filter()
+	return m.input.Focus()
+}
+
+// SetInput replaces the input, e.g. with a command waiting for its argument.
+func (m *Model) SetInput(value string) {
+	m.input.SetValue(value)
+	m.input.CursorEnd()
+	m.filter()
+}
+
+// SetSize implements the Component interface.
+func (m *Model) SetSize(width, height int) tea.Cmd {
+	m.Width = width
+	m.Height = height
+	m.input.SetWidth(max(0, width-lipgloss.Width(m.input.Prompt)-1))
+	m.scrollToCursor()
+	return nil
+}
+
+// SetError shows err below the commands, until the input changes.
+func (m *Model) SetError(err error) {
+	m.err = err
+}
+
+func (m Model) Update(msg tea.Msg) (Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyPressMsg); ok {
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			m.move(-1)
+			return m, nil
+		case key.Matches(msg, m.keys.Down):
+			m.move(1)
+			return m, nil
+		case key.Matches(msg, m.keys.Complete):
+			m.complete()
+			return m, nil
+		}
+	}
+
+	value := m.input.Value()
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	if m.input.Value() != value {
+		m.filter()
+	}
+	return m, cmd
+}
+
+// Selected returns the command under the cursor and its argument: the value
+// under the cursor, or else the argument as typed.
+func (m *Model) Selected() (string, string, bool) {
+	if m.cursor >= len(m.items) {
+		return "", "", false
+	}
+	it := m.items[m.cursor]
+	arg := it.value
+	if arg == "" {
+		_, arg = m.parse()
+	}
+	return m.commands[it.command].Name, arg, true
+}
+
+// parse splits the input into the name of a command and its argument.
+func (m *Model) parse() (string, string) {
+	name, arg, _ := strings.Cut(strings.TrimLeft(m.input.Value(), ": "), " ")
+	return name, strings.TrimSpace(arg)
+}
+
+// filter lists the commands matching the input or, once a command is typed
+// followed by a space, its values matching the argument.
+func (m *Model) filter() {
+	m.items = m.items[:0]
+	m.cursor = 0
+	m.offset = 0
+	m.err = nil
+
+	name, arg := m.parse()
+	if !strings.Contains(strings.TrimLeft(m.input.Value(), ": "), " ") {
+		for i, c := range m.commands {
+			nameScore, positions, nameOK := match(name, c.Name)
+			descScore, descPositions, descOK := match(name, c.Desc)
+			switch {
+			case nameOK && (!descOK || nameScore >= descScore):
+				m.items = append(m.items, item{command: i, positions: positions, score: nameScore})
+			case descOK:
+				m.items = append(m.items, item{command: i, descPositions: descPositions, score: descScore})
+			}
+		}
+		m.sortItems()
+		return
+	}
+
+	i := m.find(name)
+	if i < 0 {
+		return
+	}
+	for _, value := range m.commands[i].Values {
+		if score, positions, ok := match(arg, value); ok {
+			m.items = append(m.items, item{command: i, value: value, positions: positions, score: score})
+		}
+	}
+	m.sortItems()
+	if len(m.items) == 0 {
+		m.items = append(m.items, item{command: i})
+	}
+}
+
+func (m *Model) sortItems() {
+	slices.SortStableFunc(m.items, func(a, b item) int {
+		return cmp.Compare(b.score, a.score)
+	})
+}
+
+// find returns the index of the command of the given name, or else of the
+// one matching it best, or -1.
+func (m *Model) find(name string) int {
+	best, bestScore := -1, 0
+	for i, c := range m.commands {
+		if strings.EqualFold(c.Name, name) {
+			return i
+		}
+		if score, _, ok := match(name, c.Name); ok && (best < 0 || score > bestScore) {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// complete replaces the input with the command, or the value, under the
+// cursor.
+func (m *Model) complete() {
+	if m.cursor >= len(m.items) {
+		return
+	}
+	it := m.items[m.cursor]
+	m.input.SetValue(m.commands[it.command].Name + " " + it.value)
+	m.input.CursorEnd()
+	m.filter()
+}
+
+func (m *Model) move(delta int) {
+	if len(m.items) == 0 {
+		return
+	}
+	m.cursor = max(0, min(len(m.items)-1, m.cursor+delta))
+	m.scrollToCursor()
+}
+
+// listHeight returns the number of items shown below the input.
+func (m *Model) listHeight() int {
+	height := m.Height - 2
+	if m.err != nil {
+		height--
+	}
+	return max(0, height)
+}
+
+func (m *Model) scrollToCursor() {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if h := m.listHeight(); h > 0 && m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+}
+
+func (m *Model) View() string {
+	t := theme.Current()
+	lines := []string{
+		m.input.View(),
+		lipgloss.NewStyle().Foreground(t.Muted).Render(strings.Repeat("─", m.Width)),
+	}
+
+	end := min(len(m.items), m.offset+m.listHeight())
+	nameWidth := 0
+	for _, it := range m.items[m.offset:end] {
+		nameWidth = max(nameWidth, lipgloss.Width(m.commands[it.command].Name))
+	}
+	for i := m.offset; i < end; i++ {
+		lines = append(lines, m.itemView(i, nameWidth))
+	}
+	if len(m.items) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Foreground(t.Muted).Render(" No matching commands"))
+	}
+	if m.err != nil {
+		lines = append(lines, lipgloss.NewStyle().Foreground(t.Deleted).
+			Render(utils.TruncateString(" "+m.err.Error(), m.Width)))
+	}
+
+	return lipgloss.NewStyle().
+		Width(m.Width).
+		Height(m.Height).
+		MaxHeight(m.Height).
+		Render(strings.Join(lines, "\n"))
+}
+
+func (m *Model) itemView(i, nameWidth int) string {
+	t := theme.Current()
+	it := m.items[i]
+	c := m.commands[it.command]
+
+	base := lipgloss.NewStyle()
+	if i == m.cursor {
+		base = base.Background(t.Cursor).Bold(true)
+	}
+	matched := base.Foreground(t.Accent)
+	muted := base.Foreground(t.Muted)
+
+	var label string
+	if it.value != "" {
+		value := utils.TruncateMiddle(it.value, m.Width-lipgloss.Width(c.Name)-3)
+		label = muted.Render(" "+c.Name+" ") + lipgloss.StyleRunes(value, it.positions, matched, base)
+	} else {
+		name := c.Name + strings.Repeat(" ", nameWidth-lipgloss.Width(c.Name))
+		descWidth := m.Width - nameWidth - lipgloss.Width(c.Key) - 5
+		desc := utils.TruncateString(c.Desc, max(0, descWidth))
+		label = base.Render(" ") + lipgloss.StyleRunes(name, it.positions, matched, base) +
+			base.Render("  ") + lipgloss.StyleRunes(desc, it.descPositions, matched, muted)
+	}
+
+	key := ""
+	if it.value == "" && c.Key != "" {
+		key = muted.Render(c.Key + " ")
+	}
+	gap := max(1, m.Width-lipgloss.Width(label)-lipgloss.Width(key))
+	return label + base.Render(strings.Repeat(" ", gap)) + key
+}
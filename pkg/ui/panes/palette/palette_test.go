@@ -0,0 +1,81 @@
+package palette
+
+import (
+	"testing"
+
+	tea "charm.land/bubbletea/v2"
+)
+
+func TestMatch(t *testing.T) {
+	if _, _, ok := match("nf", "nextFile"); !ok {
+		t.Fatal("expected the runes to match in order")
+	}
+	if _, _, ok := match("fn", "nextFile"); ok {
+		t.Fatal("expected runes out of order not to match")
+	}
+	if _, positions, _ := match("NF", "nextFile"); len(positions) != 2 || positions[1] != 4 {
+		t.Fatalf("expected a case-insensitive match on the word start, got %v", positions)
+	}
+
+	words, _, _ := match("tv", "toggleViewed")
+	scattered, _, _ := match("tv", "toggle side-by-side view")
+	if words <= scattered {
+		t.Fatalf("expected word starts to score higher, got %d <= %d", words, scattered)
+	}
+}
+
+func TestPaletteFiltersCommandsAndValues(t *testing.T) {
+	m := New()
+	m.SetSize(60, 10)
+	m.Open([]Command{
+		{Name: "nextFile", Desc: "next file", Key: "n"},
+		{Name: "sort", Desc: "set the sort mode", Values: []string{"tree", "churn", "status"}},
+		{Name: "goto", Desc: "go to a file", Values: []string{"pkg/ui/tui.go", "README.md"}},
+	})
+	typeText := func(s string) {
+		for _, r := range s {
+			m, _ = m.Update(tea.KeyPressMsg(tea.Key{Text: string(r), Code: r}))
+		}
+	}
+
+	typeText("nxf")
+	if name, _, _ := m.Selected(); name != "nextFile" {
+		t.Fatalf("expected the fuzzy match to be selected, got %q", name)
+	}
+
+	m.Open(m.commands)
+	typeText("sort ch")
+	if name, arg, _ := m.Selected(); name != "sort" || arg != "churn" {
+		t.Fatalf("expected the matching value to be selected, got %q %q", name, arg)
+	}
+
+	m.Open(m.commands)
+	typeText("goto tui.go:120")
+	if name, arg, _ := m.Selected(); name != "goto" || arg != "tui.go:120" {
+		t.Fatalf("expected the argument as typed when no value matches, got %q %q", name, arg)
+	}
+
+	m.Open(m.commands)
+	typeText("so")
+	m, _ = m.Update(tea.KeyPressMsg(tea.Key{Code: tea.KeyTab}))
+	if got := m.input.Value(); got != "sort " {
+		t.Fatalf("expected tab to complete the command, got %q", got)
+	}
+	if len(m.items) != 3 {
+		t.Fatalf("expected the values of the command to be listed, got %d items", len(m.items))
+	}
+}
+
+func TestPaletteSetInput(t *testing.T) {
+	m := New()
+	m.SetSize(60, 10)
+	m.Open([]Command{
+		{Name: "nextFile", Desc: "next file", Key: "n"},
+		{Name: "export", Desc: "write the patch to a path"},
+	})
+	m.SetInput("export ")
+	m, _ = m.Update(tea.KeyPressMsg(tea.Key{Text: "a", Code: 'a'}))
+	if name, arg, _ := m.Selected(); name != "export" || arg != "a" {
+		t.Fatalf("expected typing to follow the input set, got %q %q", name, arg)
+	}
+}
@@ -0,0 +1,226 @@
+// Package symbols implements the outline pane listing the symbols changed by
+// the files under the cursor.
+package symbols
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/outline"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/ui/common"
+	"github.com/dlvhdr/diffnav/pkg/utils"
+)
+
+// Entry is a symbol of one of the outlined files.
+type Entry struct {
+	File   *gitdiff.File
+	Symbol outline.Symbol
+}
+
+// LoadedMsg carries the symbols found by Load, for the outline of the given
+// generation.
+type LoadedMsg struct {
+	Gen     int
+	Entries []Entry
+}
+
+// Load finds the symbols of the supported files in the background, as it
+// reads the files' blobs from git. gen is the generation of the outline the
+// symbols are loaded for.
+func Load(files []*gitdiff.File, root string, gen int) tea.Cmd {
+	return func() tea.Msg {
+		var entries []Entry
+		for _, file := range files {
+			if !outline.Supports(file) {
+				continue
+			}
+			for _, symbol := range outline.Symbols(file, outline.LoadBlobs(file, root)) {
+				entries = append(entries, Entry{File: file, Symbol: symbol})
+			}
+		}
+		return LoadedMsg{Gen: gen, Entries: entries}
+	}
+}
+
+type Model struct {
+	common.Common
+	entries   []Entry
+	supported bool
+	loading   bool
+	cursor    int
+	offset    int
+	// gen changes whenever the pane is reset, to drop the symbols of files
+	// loaded before.
+	gen int
+}
+
+func New() Model {
+	return Model{}
+}
+
+// Reset clears the pane before the symbols of files are loaded.
+func (m *Model) Reset(files []*gitdiff.File) {
+	m.gen++
+	m.entries = nil
+	m.cursor = 0
+	m.offset = 0
+	m.loading = true
+	m.supported = false
+	for _, file := range files {
+		if outline.Supports(file) {
+			m.supported = true
+			break
+		}
+	}
+}
+
+// Gen returns the generation of the pane, which symbols are loaded for.
+func (m *Model) Gen() int {
+	return m.gen
+}
+
+func (m *Model) SetEntries(entries []Entry) {
+	m.entries = entries
+	m.loading = false
+	m.cursor = 0
+	m.offset = 0
+}
+
+// SetSize implements the Component interface.
+func (m *Model) SetSize(width, height int) tea.Cmd {
+	m.Width = width
+	m.Height = height
+	m.scrollToCursor()
+	return nil
+}
+
+// Move moves the cursor by delta entries and reports whether it moved.
+func (m *Model) Move(delta int) bool {
+	if len(m.entries) == 0 {
+		return false
+	}
+	cursor := max(0, min(len(m.entries)-1, m.cursor+delta))
+	if cursor == m.cursor {
+		return false
+	}
+	m.cursor = cursor
+	m.scrollToCursor()
+	return true
+}
+
+// Selected returns the entry under the cursor.
+func (m *Model) Selected() (Entry, bool) {
+	if m.cursor < 0 || m.cursor >= len(m.entries) {
+		return Entry{}, false
+	}
+	return m.entries[m.cursor], true
+}
+
+func (m *Model) scrollToCursor() {
+	row := m.cursorRow()
+	if row < m.offset {
+		m.offset = row
+	}
+	if m.Height > 0 && row >= m.offset+m.Height {
+		m.offset = row - m.Height + 1
+	}
+}
+
+// cursorRow returns the row of the cursor, counting the file headers.
+func (m *Model) cursorRow() int {
+	rows := m.rows()
+	for i, row := range rows {
+		if row.entry == m.cursor {
+			return i
+		}
+	}
+	return 0
+}
+
+type row struct {
+	// entry is the index of the entry on the row, or -1 for file headers.
+	entry int
+	file  *gitdiff.File
+}
+
+func (m *Model) rows() []row {
+	var rows []row
+	var last *gitdiff.File
+	for i, entry := range m.entries {
+		if entry.File != last {
+			rows = append(rows, row{entry: -1, file: entry.File})
+			last = entry.File
+		}
+		rows = append(rows, row{entry: i, file: entry.File})
+	}
+	return rows
+}
+
+func (m *Model) View() string {
+	t := theme.Current()
+	dim := lipgloss.NewStyle().Foreground(t.Muted)
+	style := lipgloss.NewStyle().Width(m.Width).Height(m.Height).MaxHeight(m.Height)
+
+	switch {
+	case !m.supported:
+		return style.Render(dim.Render(" No outline for these files"))
+	case m.loading:
+		return style.Render(dim.Render(" Loading symbols…"))
+	case len(m.entries) == 0:
+		return style.Render(dim.Render(" No changed symbols"))
+	}
+
+	rows := m.rows()
+	end := min(len(rows), m.offset+m.Height)
+	lines := make([]string, 0, end-m.offset)
+	for _, r := range rows[m.offset:end] {
+		if r.entry < 0 {
+			name := utils.TruncateMiddle(filenode.GetFileName(r.file), m.Width-1)
+			lines = append(lines, lipgloss.NewStyle().Foreground(t.Directory).Render(" "+name))
+			continue
+		}
+		lines = append(lines, m.entryView(r.entry))
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+func (m *Model) entryView(i int) string {
+	t := theme.Current()
+	symbol := m.entries[i].Symbol
+	icon, color := changeIcon(symbol.Change)
+	prefix := " " + lipgloss.NewStyle().Foreground(color).Render(icon) + " "
+	// Symbols named only by a hunk header have no line of their own.
+	line := fmt.Sprintf(" hunk %d", symbol.Hunk+1)
+	if symbol.Start > 0 {
+		line = fmt.Sprintf(" L%d", symbol.Start)
+	}
+	label := utils.TruncateString(symbol.Label(), m.Width-lipgloss.Width(prefix)-len(line))
+
+	if i == m.cursor {
+		return prefix + lipgloss.NewStyle().
+			Background(t.Cursor).
+			Bold(true).
+			Width(max(0, m.Width-lipgloss.Width(prefix))).
+			Render(label+line)
+	}
+	return prefix + label + lipgloss.NewStyle().Foreground(t.Muted).Render(line)
+}
+
+func changeIcon(change outline.Change) (string, color.Color) {
+	t := theme.Current()
+	switch change {
+	case outline.Added:
+		return "+", t.Added
+	case outline.Removed:
+		return "-", t.Deleted
+	default:
+		return "~", t.Modified
+	}
+}
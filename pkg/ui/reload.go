@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/log"
+
+	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+)
+
+// configPollInterval is how often the config files are checked for changes.
+const configPollInterval = time.Second
+
+// ConfigLoader reads the config again, along with the theme it selects.
+type ConfigLoader func() (config.Config, theme.Theme, error)
+
+// configWatcher polls the modification times of the config files, as they
+// are rewritten rather than modified in place by most editors.
+type configWatcher struct {
+	paths  []string
+	load   ConfigLoader
+	stamps map[string]time.Time
+}
+
+// configPollMsg holds the modification times of the config files.
+type configPollMsg struct {
+	stamps map[string]time.Time
+}
+
+type configReloadedMsg struct {
+	cfg   config.Config
+	theme theme.Theme
+	err   error
+}
+
+// WatchConfig reloads the config with load whenever one of the files at
+// paths is created, modified or removed.
+func (m *mainModel) WatchConfig(paths []string, load ConfigLoader) {
+	m.watcher = &configWatcher{paths: paths, load: load, stamps: modTimes(paths)}
+}
+
+// modTimes returns the modification time of each file, the zero time for
+// the files that don't exist.
+func modTimes(paths []string) map[string]time.Time {
+	stamps := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stamps[path] = info.ModTime()
+		} else {
+			stamps[path] = time.Time{}
+		}
+	}
+	return stamps
+}
+
+func (w *configWatcher) poll() tea.Cmd {
+	if w == nil {
+		return nil
+	}
+	return tea.Tick(configPollInterval, func(time.Time) tea.Msg {
+		return configPollMsg{stamps: modTimes(w.paths)}
+	})
+}
+
+// changed reports whether the modification times differ from the ones last
+// seen, and remembers them.
+func (w *configWatcher) changed(stamps map[string]time.Time) bool {
+	if maps.EqualFunc(w.stamps, stamps, time.Time.Equal) {
+		return false
+	}
+	w.stamps = stamps
+	return true
+}
+
+func (w *configWatcher) reload() tea.Msg {
+	cfg, th, err := w.load()
+	return configReloadedMsg{cfg: cfg, theme: th, err: err}
+}
+
+// applyConfig updates a running session to a reloaded config. The settings
+// toggled in the session, such as the icon style or the sort, are kept unless
+// the config changes them.
+func (m mainModel) applyConfig(cfg config.Config) (mainModel, tea.Cmd) {
+	var cmds []tea.Cmd
+	old := m.config
+	m.config = cfg
+
+	keys, err := NewKeyMap(cfg.Keys)
+	if err != nil {
+		log.Error("invalid key bindings, keeping the previous ones", "err", err)
+	} else {
+		m.keys = keys
+		// A pending sequence refers to the previous bindings.
+		m.pending = nil
+		m.fileTree.SetKeyMap(keys.fileTreeKeys())
+		m.diffViewer.SetKeyMap(keys.diffViewerKeys())
+		m.palette.SetKeyMap(keys.paletteKeys())
+		m.help.SetKeys(keys.Groups())
+	}
+	m.help.UpdateStyles()
+	m.updateSearchStyles()
+
+	if cfg.UI.Icons != old.UI.Icons {
+		m.iconStyle = cfg.UI.Icons
+	}
+	if cfg.UI.ShowFileTree != old.UI.ShowFileTree {
+		m.isShowingFileTree = cfg.UI.ShowFileTree
+		if !m.isShowingFileTree {
+			m.activePanel = DiffViewerPanel
+		}
+	}
+	treeCfg := cfg
+	treeCfg.UI.Icons = m.iconStyle
+	if cfg.UI.FileTreeMode == old.UI.FileTreeMode {
+		treeCfg.UI.FileTreeMode = m.fileTree.Mode()
+	}
+	m.fileTree.SetConfig(treeCfg)
+	if cfg.UI.FileTreeWidth != old.UI.FileTreeWidth {
+		m.treeWidth = cfg.UI.FileTreeWidth
+	}
+	if cfg.UI.FileTreeHeight != old.UI.FileTreeHeight {
+		m.treeHeight = cfg.UI.FileTreeHeight
+	}
+
+	if !reflect.DeepEqual(cfg.Renderer.Delta, old.Renderer.Delta) {
+		m.diffViewer.SetDeltaConfig(cfg.Renderer.Delta)
+	}
+	if cfg.UI.SideBySide != old.UI.SideBySide {
+		m.sideBySide = cfg.UI.SideBySide
+		// The diff is rendered again by resize below.
+		_ = m.diffViewer.SetSideBySide(m.sideBySide)
+	}
+	if cfg.UI.AutoSideBySide != old.UI.AutoSideBySide ||
+		cfg.UI.AutoSideBySideWidth != old.UI.AutoSideBySideWidth {
+		autoWidth := 0
+		if cfg.UI.AutoSideBySide {
+			autoWidth = cfg.UI.AutoSideBySideWidth
+		}
+		m.diffViewer.SetAutoSideBySide(autoWidth)
+	}
+	// Resizing the diff viewer drops its cache, so the diffs are rendered
+	// again with the new theme.
+	cmds = append(cmds, m.resize())
+
+	if cfg.UI.Sort != old.UI.Sort {
+		m.sortMode = cfg.UI.Sort
+	}
+	switch {
+	case !slices.Equal(cfg.UI.Exclude, old.UI.Exclude) ||
+		!slices.Equal(cfg.UI.Collapse, old.UI.Collapse) ||
+		cfg.UI.Generated != old.UI.Generated:
+		// The files to show changed, so the diff is parsed again.
+		cmds = append(cmds, m.fetchFileTree)
+	case cfg.UI.Sort != old.UI.Sort ||
+		!slices.Equal(cfg.UI.ReviewOrder.First, old.UI.ReviewOrder.First) ||
+		!slices.Equal(cfg.UI.ReviewOrder.Last, old.UI.ReviewOrder.Last):
+		var cmd tea.Cmd
+		m, cmd = m.resortFiles()
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
+}
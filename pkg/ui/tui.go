@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"charm.land/bubbles/v2/key"
@@ -16,21 +18,31 @@ import (
 	"github.com/charmbracelet/log"
 	zone "github.com/lrstanley/bubblezone/v2"
 
+	"github.com/dlvhdr/diffnav/pkg/codeowners"
 	"github.com/dlvhdr/diffnav/pkg/config"
 	"github.com/dlvhdr/diffnav/pkg/dirnode"
+	"github.com/dlvhdr/diffnav/pkg/editor"
+	"github.com/dlvhdr/diffnav/pkg/exclude"
 	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/filesort"
+	"github.com/dlvhdr/diffnav/pkg/hunknode"
+	"github.com/dlvhdr/diffnav/pkg/theme"
 	"github.com/dlvhdr/diffnav/pkg/ui/common"
 	"github.com/dlvhdr/diffnav/pkg/ui/panes/diffviewer"
 	"github.com/dlvhdr/diffnav/pkg/ui/panes/filetree"
 	"github.com/dlvhdr/diffnav/pkg/ui/panes/help"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/palette"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/symbols"
 	"github.com/dlvhdr/diffnav/pkg/utils"
+	"github.com/dlvhdr/diffnav/pkg/viewed"
 )
 
 const (
-	minResizeStep = 6
-	footerHeight  = 1
-	headerHeight  = 2
-	searchHeight  = 3
+	minResizeStep   = 6
+	footerHeight    = 1
+	headerHeight    = 2
+	searchHeight    = 3
+	filterBarHeight = 1
 
 	// Zone IDs for bubblezone click detection.
 	zoneSearchBox     = "searchbox"
@@ -47,6 +59,9 @@ const (
 
 	// Scroll speed in lines per wheel tick.
 	scrollLines = 3
+
+	// ownerSearchPrefix starts a search term filtering files by owner.
+	ownerSearchPrefix = "owner:"
 )
 
 type Panel int
@@ -59,6 +74,8 @@ const (
 type mainModel struct {
 	input             string
 	files             []*gitdiff.File
+	excluded          int
+	excludedBy        []string
 	fileTree          filetree.Model
 	diffViewer        diffviewer.Model
 	width             int
@@ -69,37 +86,83 @@ type mainModel struct {
 	resultsVp         viewport.Model
 	resultsCursor     int
 	searching         bool
+	filtering         bool
 	filtered          []string
 	config            config.Config
 	draggingSidebar   bool
 	iconStyle         string
 	sideBySide        bool
+	sortMode          string
 	help              help.Model
 	helpOpen          bool
+	viewed            *viewed.Store
+	owners            map[string][]string
+	outline           symbols.Model
+	outlineOpen       bool
+	keys              *KeyMap
+	watcher           *configWatcher
+	// treeWidth is the width of the sidebar beside the diff, and treeHeight
+	// its height when stacked above it. Both can be changed by dragging the
+	// sidebar's border.
+	treeWidth  int
+	treeHeight int
+	// configErr is set when the last reload of the config failed.
+	configErr bool
+	// count is the count typed before a motion, and pending the binding of
+	// the first key of a two-key motion like gg.
+	count       int
+	pending     *key.Binding
+	palette     palette.Model
+	paletteOpen bool
+	// statusErr is shown in the footer until the next key press.
+	statusErr error
+	jumps     *jumpList
+	// draggingDiff is set while the mouse is dragged over the diff from
+	// dragRow, selecting rows.
+	draggingDiff bool
+	dragRow      int
+}
+
+// editorClosedMsg is sent when the editor opened on a file exits, or fails to
+// start.
+type editorClosedMsg struct {
+	err error
 }
 
 func New(input string, cfg config.Config) mainModel {
 	m := mainModel{
 		input: input, isShowingFileTree: cfg.UI.ShowFileTree,
 		activePanel: FileTreePanel, config: cfg, iconStyle: cfg.UI.Icons, sideBySide: cfg.UI.SideBySide,
+		sortMode: cfg.UI.Sort, viewed: viewed.Load(), jumps: &jumpList{},
+		treeWidth: cfg.UI.FileTreeWidth, treeHeight: cfg.UI.FileTreeHeight,
 	}
+	keys, err := NewKeyMap(cfg.Keys)
+	if err != nil {
+		log.Error("invalid key bindings, using the defaults", "err", err)
+		keys, _ = NewKeyMap(nil)
+	}
+	m.keys = keys
 	m.fileTree = filetree.New(cfg)
 	m.fileTree.SetSize(cfg.UI.FileTreeWidth, 0)
+	m.fileTree.SetKeyMap(keys.fileTreeKeys())
 	m.diffViewer = diffviewer.New(cfg.UI.SideBySide)
+	m.diffViewer.SetDeltaConfig(cfg.Renderer.Delta)
+	if cfg.UI.AutoSideBySide {
+		m.diffViewer.SetAutoSideBySide(cfg.UI.AutoSideBySideWidth)
+	}
+	m.diffViewer.SetKeyMap(keys.diffViewerKeys())
 	m.help = help.New()
-	m.help.SetKeys(KeyGroups())
+	m.help.SetKeys(keys.Groups())
+	m.outline = symbols.New()
+	m.palette = palette.New()
+	m.palette.SetKeyMap(keys.paletteKeys())
 
 	m.search = textinput.New()
 	m.search.ShowSuggestions = true
 	m.search.KeyMap.AcceptSuggestion = key.NewBinding(key.WithKeys("tab"))
 	m.search.Prompt = " "
 	m.search.Placeholder = "Filter files 󰬛 "
-	m.search.SetStyles(textinput.Styles{
-		Focused: textinput.StyleState{
-			Placeholder: lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
-			Prompt:      lipgloss.NewStyle().Foreground(lipgloss.Color("8")),
-		},
-	})
+	m.updateSearchStyles()
 	m.search.SetWidth(cfg.UI.FileTreeWidth - 2)
 
 	m.resultsVp = viewport.Model{}
@@ -107,16 +170,41 @@ func New(input string, cfg config.Config) mainModel {
 	return m
 }
 
+// updateSearchStyles applies the colors of the current theme to the search
+// box.
+func (m *mainModel) updateSearchStyles() {
+	m.search.SetStyles(textinput.Styles{
+		Focused: textinput.StyleState{
+			Placeholder: lipgloss.NewStyle().Foreground(theme.Current().Muted),
+			Prompt:      lipgloss.NewStyle().Foreground(theme.Current().Muted),
+		},
+	})
+}
+
 func (m mainModel) Init() tea.Cmd {
-	return tea.Batch(m.fetchFileTree, m.diffViewer.Init())
+	return tea.Batch(m.fetchFileTree, m.diffViewer.Init(), m.watcher.poll())
 }
 
+// Update handles msg and records the location it started from in the jump
+// history, if it moved the cursor to another node.
 func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	from, ok := m.location()
+	gen := m.jumps.gen
+	updated, cmd := m.update(msg)
+	m = updated.(mainModel)
+	m.recordJump(from, ok, gen)
+	return m, cmd
+}
+
+func (m mainModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
 	// Handle mouse events regardless of search mode
 	if msg, ok := msg.(tea.MouseMsg); ok {
+		if m.allExcluded() {
+			return m, nil
+		}
 		return m.handleMouse(msg)
 	}
 
@@ -129,94 +217,46 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
+		m.statusErr = nil
 		switch {
-		case key.Matches(msg, keys.ToggleHelp):
+		case m.allExcluded():
+			if key.Matches(msg, m.keys.Quit) || msg.Key().Code == tea.KeyEscape {
+				return m, tea.Quit
+			}
+			return m, nil
+		case m.paletteOpen:
+			m, cmd = m.paletteUpdate(msg)
+			return m, cmd
+		case key.Matches(msg, m.keys.ToggleHelp):
 			m.helpOpen = !m.helpOpen
 			return m, tea.Batch(cmds...)
-		case m.helpOpen && (key.Matches(msg, keys.Quit) || msg.Key().Code == tea.KeyEscape):
+		case m.helpOpen && (key.Matches(msg, m.keys.Quit) || msg.Key().Code == tea.KeyEscape):
 			m.helpOpen = false
 			return m, tea.Batch(cmds...)
 		case m.helpOpen:
 			// Block all other keys while help is open
 			return m, tea.Batch(cmds...)
-		case key.Matches(msg, keys.Quit):
-			return m, tea.Quit
-		case key.Matches(msg, keys.Search):
-			m.searching = true
-			m.search.SetWidth(m.searchWidth())
-			m.search.SetValue("")
-			m.resultsCursor = 0
-			m.setSearchResults()
-
-			m.resultsVp.SetWidth(m.config.UI.SearchTreeWidth)
-			m.resultsVp.SetHeight(m.mainContentHeight() - searchHeight)
-			m.resultsVp.SetContent(m.resultsView())
-
-			dfCmd := m.diffViewer.SetSize(m.width-m.sidebarWidth(), m.mainContentHeight())
-			cmds = append(cmds, dfCmd, m.search.Focus())
-		case key.Matches(msg, keys.ToggleFileTree):
-			m.isShowingFileTree = !m.isShowingFileTree
-			sidebarWidth := m.sidebarWidth()
-			h := m.mainContentHeight()
-
-			if !m.isShowingFileTree {
-				m.activePanel = DiffViewerPanel
-			} else {
-				m.activePanel = FileTreePanel
-			}
-			treeWidth := sidebarWidth
-			if sidebarWidth == 0 {
-				treeWidth = m.config.UI.FileTreeWidth
-			}
+		case m.filtering:
+			m, cmd = m.filterUpdate(msg)
+			return m, cmd
+		case m.outlineOpen:
+			m, cmd = m.outlineUpdate(msg)
+			return m, cmd
+		case m.diffViewer.Selecting():
+			m, cmd = m.visualUpdate(msg)
+			return m, cmd
+		}
 
-			m.fileTree.SetSize(treeWidth, h-searchHeight)
-			m.search.SetWidth(m.searchWidth())
-			dfCmd := m.diffViewer.SetSize(m.width-sidebarWidth, h)
-			cmds = append(cmds, dfCmd)
-		case key.Matches(msg, keys.ToggleIconStyle):
-			m.cycleIconStyle()
-		case key.Matches(msg, keys.ToggleDiffView):
-			m.sideBySide = !m.sideBySide
-			cmd = m.diffViewer.SetSideBySide(m.sideBySide)
-			cmds = append(cmds, cmd)
-		case key.Matches(msg, keys.SwitchPanel):
-			if m.isShowingFileTree {
-				if m.activePanel == FileTreePanel {
-					m.activePanel = DiffViewerPanel
-				} else {
-					m.activePanel = FileTreePanel
-				}
-			}
-		case key.Matches(msg, keys.PrevFile):
-			m, cmd = m.moveToFile(-1)
-			cmds = append(cmds, cmd)
-		case key.Matches(msg, keys.NextFile):
-			m, cmd = m.moveToFile(1)
-			cmds = append(cmds, cmd)
-		case key.Matches(msg, keys.Up):
-			if m.activePanel == FileTreePanel {
-				m, cmd = m.moveCursor(-1)
-				cmds = append(cmds, cmd)
-			} else {
-				m.diffViewer.ScrollUp(1)
-			}
-		case key.Matches(msg, keys.Down):
-			if m.activePanel == FileTreePanel {
-				m, cmd = m.moveCursor(1)
-				cmds = append(cmds, cmd)
-			} else {
-				m.diffViewer.ScrollDown(1)
-			}
-		case key.Matches(msg, keys.Copy):
-			cmd = m.fileTree.CopyCurrNodePath()
-			if cmd != nil {
-				cmds = append(cmds, cmd)
-			}
-		case key.Matches(msg, keys.OpenInEditor):
-			cmd = m.openInEditor()
-			if cmd != nil {
-				cmds = append(cmds, cmd)
-			}
+		var handled bool
+		if m, cmd, handled = m.motionUpdate(msg); handled {
+			return m, cmd
+		}
+
+		// The panes run their own actions as the key is routed to them below.
+		// Other actions use the key up, so that no pane acts on it too.
+		if b := m.keys.mainAction(msg); b != nil && !slices.Contains(m.keys.paneActions(), b) {
+			m, cmd = m.runAction(b, 0)
+			return m, tea.Batch(append(cmds, cmd)...)
 		}
 
 	case tea.WindowSizeMsg:
@@ -224,35 +264,82 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.help.Update(msg)
 		m.width = msg.Width
 		m.height = msg.Height
-		dfCmd := m.diffViewer.SetSize(m.width-m.sidebarWidth(), m.mainContentHeight())
-		cmds = append(cmds, dfCmd)
+		cmds = append(cmds, m.resize())
 
-		tWidth, tHeight := m.sidebarWidth(), m.mainContentHeight()-searchHeight
+	case configPollMsg:
+		if m.watcher.changed(msg.stamps) {
+			cmds = append(cmds, m.watcher.reload)
+		}
+		cmds = append(cmds, m.watcher.poll())
 
-		m.fileTree.SetSize(tWidth, tHeight)
-		m.search.SetWidth(m.searchWidth())
+	case configReloadedMsg:
+		if msg.err != nil {
+			log.Error("failed to reload the config, keeping the previous one", "err", msg.err)
+			m.configErr = true
+			break
+		}
+		log.Info("reloaded the config")
+		m.configErr = false
+		theme.Set(msg.theme)
+		m, cmd = m.applyConfig(msg.cfg)
+		cmds = append(cmds, cmd)
 
 	case fileTreeMsg:
 		m.files = msg.files
+		m.excluded = msg.excluded
 		if len(m.files) == 0 {
-			return m, tea.Quit
+			// Say why the files are missing rather than quitting as if the
+			// diff was empty.
+			if m.excluded == 0 {
+				return m, tea.Quit
+			}
+			m.excludedBy = msg.excludedBy
+			return m, nil
+		}
+		m.owners = msg.owners
+		m.fileTree.SetCollapsed(msg.collapsed)
+		m.fileTree.SetOwners(msg.owners)
+		m.diffViewer.SetOwners(msg.owners)
+		for _, file := range m.files {
+			if m.viewed.IsViewed(file) {
+				m.fileTree.SetViewed(filenode.GetFileName(file), true)
+			}
 		}
 		m.fileTree = m.fileTree.SetFiles(m.files)
 		m.diffViewer.SetPreamble(strings.TrimSpace(msg.preamble))
 		m.diffViewer, cmd = m.diffViewer.SetDirPatch("/", m.fileTree.GetCurrNodeDesendantDiffs())
 		cmds = append(cmds, cmd)
 
+	case symbols.LoadedMsg:
+		// Symbols loaded for other files, before the outline was reset, are
+		// dropped.
+		if msg.Gen == m.outline.Gen() {
+			m.outline.SetEntries(msg.Entries)
+		}
+
+	case editorClosedMsg:
+		if msg.err != nil {
+			log.Error("failed to open the editor", "err", msg.err)
+			m.statusErr = fmt.Errorf("editor: %w", msg.err)
+		}
+
+	case copiedMsg:
+		if msg.err != nil {
+			log.Error("failed to copy to the clipboard", "err", msg.err)
+			m.statusErr = fmt.Errorf("copy: %w", msg.err)
+		}
+
 	case common.ErrMsg:
 		fmt.Printf("Error: %v\n", msg.Err)
 		log.Fatal(msg.Err)
 	}
 
 	// Route messages: key messages go only to active panel, other messages go to both.
-	// Exception: ctrl+d/ctrl+u always go to diffViewer for scrolling.
+	// Exception: ctrl+d/ctrl+u and ctrl+f/ctrl+b always go to diffViewer for scrolling.
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+d", "ctrl+u":
+		switch {
+		case key.Matches(msg, m.keys.CtrlD, m.keys.CtrlU, m.keys.PageDown, m.keys.PageUp):
 			m.diffViewer, cmd = m.diffViewer.Update(msg)
 			cmds = append(cmds, cmd)
 		default:
@@ -269,11 +356,32 @@ func (m mainModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 		m.fileTree.Update(msg)
 		cmds = append(cmds, cmd)
+		if m.paletteOpen {
+			m.palette, cmd = m.palette.Update(msg)
+			cmds = append(cmds, cmd)
+		}
 	}
 
 	return m, tea.Batch(cmds...)
 }
 
+// resize lays out the panes for the window size and the layout, and
+// re-renders the diff.
+func (m *mainModel) resize() tea.Cmd {
+	cmd := m.diffViewer.SetSize(m.diffWidth(), m.diffHeight())
+
+	tWidth, tHeight := m.sidebarWidth(), m.fileTreeHeight()
+	m.fileTree.SetSize(tWidth, tHeight)
+	m.outline.SetSize(tWidth, m.outlineHeight())
+	m.search.SetWidth(m.searchWidth())
+	m.palette.SetSize(m.paletteSize())
+	if m.searching {
+		m.resultsVp.SetWidth(tWidth)
+		m.resultsVp.SetHeight(m.sidebarHeight() - searchHeight)
+	}
+	return cmd
+}
+
 func (m *mainModel) mainContentHeight() int {
 	return m.height - m.headerHeight() - m.footerHeight()
 }
@@ -296,22 +404,144 @@ func (m *mainModel) cycleIconStyle() {
 	m.fileTree.SetIconStyle(m.iconStyle)
 }
 
+// resortFiles re-sorts the files using the current sort mode and rebuilds the
+// file tree, keeping the cursor on the same node.
+func (m mainModel) resortFiles() (mainModel, tea.Cmd) {
+	if len(m.files) == 0 {
+		return m, nil
+	}
+
+	path := m.fileTree.CurrNodePath()
+	filesort.Sort(m.files, m.sortMode, m.config.UI.ReviewOrder)
+	m.fileTree = m.fileTree.SetFiles(m.files)
+	m.fileTree.SetCursorByPath(path)
+
+	// Directory diffs are cached in file order, so they need to be re-rendered.
+	m.diffViewer.ClearCache()
+	return m.setNodeDiff(m.fileTree.GetCurrNode())
+}
+
+// toggleViewed marks the files under the cursor as viewed, or un-marks them
+// when they are all viewed already, and persists the marks.
+func (m *mainModel) toggleViewed() {
+	if len(m.files) == 0 {
+		return
+	}
+	files := m.fileTree.GetCurrNodeDesendantDiffs()
+	if len(files) == 0 {
+		return
+	}
+
+	mark := false
+	for _, file := range files {
+		if !m.fileTree.IsViewed(filenode.GetFileName(file)) {
+			mark = true
+			break
+		}
+	}
+	for _, file := range files {
+		m.viewed.Set(file, mark)
+		m.fileTree.SetViewed(filenode.GetFileName(file), mark)
+	}
+	m.fileTree.Refresh()
+
+	if err := m.viewed.Save(); err != nil {
+		log.Error("failed saving viewed files", "err", err)
+	}
+}
+
+func (m mainModel) viewedCount() int {
+	count := 0
+	for _, file := range m.files {
+		if m.fileTree.IsViewed(filenode.GetFileName(file)) {
+			count++
+		}
+	}
+	return count
+}
+
+func (m mainModel) filterUpdate(msg tea.KeyPressMsg) (mainModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Filter.Close):
+		m.filtering = false
+		m.fileTree.SetSize(m.sidebarWidth(), m.fileTreeHeight())
+	case key.Matches(msg, m.keys.Filter.Added):
+		return m.toggleStatusFilter(filenode.StatusAdded)
+	case key.Matches(msg, m.keys.Filter.Modified):
+		return m.toggleStatusFilter(filenode.StatusModified)
+	case key.Matches(msg, m.keys.Filter.Deleted):
+		return m.toggleStatusFilter(filenode.StatusDeleted)
+	case key.Matches(msg, m.keys.Filter.Renamed):
+		return m.toggleStatusFilter(filenode.StatusRenamed)
+	case key.Matches(msg, m.keys.Filter.Binary):
+		return m.toggleStatusFilter(filenode.StatusBinary)
+	}
+	return m, nil
+}
+
+func (m mainModel) outlineUpdate(msg tea.KeyPressMsg) (mainModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Outline.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Outline.Close):
+		m.outlineOpen = false
+	case key.Matches(msg, m.keys.Outline.Select):
+		m.outlineOpen = false
+		return m.goToSymbol()
+	case key.Matches(msg, m.keys.Outline.Up):
+		if m.outline.Move(-1) {
+			return m.goToSymbol()
+		}
+	case key.Matches(msg, m.keys.Outline.Down):
+		if m.outline.Move(1) {
+			return m.goToSymbol()
+		}
+	}
+	return m, nil
+}
+
+// goToSymbol shows the hunk of the symbol under the outline's cursor.
+func (m mainModel) goToSymbol() (mainModel, tea.Cmd) {
+	entry, ok := m.outline.Selected()
+	if !ok {
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.fileTree.SetCursorByPath(filenode.GetFileName(entry.File))
+	m.diffViewer, cmd = m.diffViewer.SetHunkPatch(entry.File, entry.Symbol.Hunk)
+	return m, cmd
+}
+
+// toggleStatusFilter shows or hides the files with the given status in the
+// tree and in directory diffs.
+func (m mainModel) toggleStatusFilter(status filenode.Status) (mainModel, tea.Cmd) {
+	m.fileTree.ToggleStatus(status)
+	m.fileTree.SetSize(m.sidebarWidth(), m.fileTreeHeight())
+	if len(m.files) == 0 {
+		return m, nil
+	}
+
+	// Directory diffs are cached with the files they contained when rendered.
+	m.diffViewer.ClearCache()
+	return m.setNodeDiff(m.fileTree.GetCurrNode())
+}
+
 func (m mainModel) searchUpdate(msg tea.Msg) (mainModel, []tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 	if m.search.Focused() {
 		switch msg := msg.(type) {
 		case tea.KeyMsg:
-			switch msg.String() {
-			case "esc":
+			switch {
+			case key.Matches(msg, m.keys.SearchBox.Close):
 				m.stopSearch()
-				dfCmd := m.diffViewer.SetSize(m.width-m.sidebarWidth(), m.mainContentHeight())
+				dfCmd := m.diffViewer.SetSize(m.diffWidth(), m.diffHeight())
 				cmds = append(cmds, dfCmd)
-			case "ctrl+c":
+			case key.Matches(msg, m.keys.SearchBox.Quit):
 				return m, []tea.Cmd{tea.Quit}
-			case "enter":
+			case key.Matches(msg, m.keys.SearchBox.Select):
 				m.stopSearch()
-				dfCmd := m.diffViewer.SetSize(m.width-m.sidebarWidth(), m.mainContentHeight())
+				dfCmd := m.diffViewer.SetSize(m.diffWidth(), m.diffHeight())
 				cmds = append(cmds, dfCmd)
 
 				if selected, ok := m.selectedSearchResult(); ok {
@@ -325,12 +555,12 @@ func (m mainModel) searchUpdate(msg tea.Msg) (mainModel, []tea.Cmd) {
 					}
 				}
 
-			case "ctrl+n", "down":
+			case key.Matches(msg, m.keys.SearchBox.Down):
 				if len(m.filtered) > 0 {
 					m.resultsCursor = min(len(m.filtered)-1, m.resultsCursor+1)
 					m.resultsVp.ScrollDown(1)
 				}
-			case "ctrl+p", "up":
+			case key.Matches(msg, m.keys.SearchBox.Up):
 				if len(m.filtered) > 0 {
 					m.resultsCursor = max(0, m.resultsCursor-1)
 					m.resultsVp.ScrollUp(1)
@@ -355,75 +585,59 @@ func (m mainModel) View() tea.View {
 	view.MouseMode = tea.MouseModeAllMotion
 
 	view.KeyboardEnhancements.ReportEventTypes = true
+	if m.allExcluded() {
+		view.Content = m.allExcludedView()
+		return view
+	}
 	// Determine colors based on active panel.
-	leftColor := lipgloss.Color("8")
-	rightColor := lipgloss.Color("8")
+	t := theme.Current()
+	leftColor := t.Muted
+	rightColor := t.Muted
 	if m.activePanel == FileTreePanel && !m.searching {
-		leftColor = lipgloss.Color("4")
+		leftColor = t.Accent
 	} else if m.activePanel == DiffViewerPanel {
-		rightColor = lipgloss.Color("4")
+		rightColor = t.Accent
 	}
 
 	// Build T-shaped separator line.
-	separator := ""
-	if m.width > 0 {
-		if m.isSidebarVisible() {
-			sidebarW := m.sidebarWidth()
-			rightW := max(m.width-sidebarW, 0)
-			leftLine := lipgloss.NewStyle().
-				Foreground(leftColor).
-				Render(strings.Repeat("─", sidebarW))
-			junction := lipgloss.NewStyle().Foreground(leftColor).Render("┬")
-			rightLine := lipgloss.NewStyle().
-				Foreground(rightColor).
-				Render(strings.Repeat("─", rightW))
-			separator = leftLine + junction + rightLine
-		} else {
-			separator = lipgloss.NewStyle().Foreground(rightColor).Render(strings.Repeat("─", m.width))
-		}
-	}
+	separator := m.separatorView(leftColor, rightColor)
 
 	sidebar := ""
 	if m.isSidebarVisible() {
 		searchBox := lipgloss.NewStyle().
 			Border(lipgloss.RoundedBorder()).
-			BorderForeground(lipgloss.Color("8")).
+			BorderForeground(t.Muted).
 			Width(m.sidebarWidth()).
 			Render(m.search.View())
 		searchBox = zone.Mark(zoneSearchBox, searchBox)
 
-		content := ""
+		parts := []string{searchBox}
 		if m.searching {
-			content = zone.Mark(zoneSearchResults, m.resultsVp.View())
+			parts = append(parts, zone.Mark(zoneSearchResults, m.resultsVp.View()))
+		} else if m.outlineOpen {
+			parts = append(parts, m.outline.View())
 		} else {
-			content = zone.Mark(zoneFileTree, m.fileTree.View())
-		}
-		content = lipgloss.NewStyle().
-			Render(lipgloss.JoinVertical(lipgloss.Left, searchBox, content))
-
-		sidebar = lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder(), false, true, false, false).
-			BorderForeground(leftColor).Render(content)
-	} else {
-		// Show a thin grab line when sidebar is hidden.
-		// Width(0) means only the border is rendered (1 char).
-		grabLine := lipgloss.NewStyle().
-			Width(0).
-			Height(m.mainContentHeight()-1).
-			Border(lipgloss.NormalBorder(), false, true, false, false).
-			BorderForeground(lipgloss.Color("8")).
-			Render("")
-		sidebar = grabLine
+			if m.isFilterBarVisible() {
+				parts = append(parts, m.filterBarView())
+			}
+			parts = append(parts, zone.Mark(zoneFileTree, m.fileTree.View()))
+		}
+		sidebar = lipgloss.JoinVertical(lipgloss.Left, parts...)
 	}
 
+	// A thin grab line is shown when the sidebar is hidden.
+	sidebarColor := leftColor
+	if !m.isSidebarVisible() {
+		sidebarColor = t.Muted
+	}
 	dv := zone.Mark(zoneDiffViewer, m.diffViewer.View())
-	mainContent := lipgloss.JoinHorizontal(lipgloss.Top, sidebar, dv)
+	mainContent := m.mainContentView(sidebar, dv, sidebarColor)
 
 	var sections []string
 
 	if !m.config.UI.HideHeader {
 		header := lipgloss.NewStyle().Width(m.width).
-			Foreground(lipgloss.Color("6")).
+			Foreground(t.Info).
 			Bold(true).
 			Render("DIFFNAV")
 		sections = append(sections, header)
@@ -446,7 +660,7 @@ func (m mainModel) View() tea.View {
 		s := lipgloss.NewStyle().
 			Border(lipgloss.NormalBorder(), true).
 			Padding(1, 3).
-			BorderForeground(lipgloss.Blue)
+			BorderForeground(t.Accent)
 		row := m.height/4 - 2 // just a bit above the center
 		col := m.width / 2
 		col -= lipgloss.Width(helpView) / 2
@@ -456,6 +670,17 @@ func (m mainModel) View() tea.View {
 		)
 	}
 
+	if m.paletteOpen {
+		s := lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder(), true).
+			Padding(0, 1).
+			BorderForeground(t.Accent)
+		box := s.Render(m.palette.View())
+		row := m.height / 6
+		col := (m.width - lipgloss.Width(box)) / 2
+		layers = append(layers, lipgloss.NewLayer(box).X(col).Y(row))
+	}
+
 	comp := lipgloss.NewCompositor(layers...)
 
 	view.Content = comp.Render()
@@ -464,8 +689,12 @@ func (m mainModel) View() tea.View {
 }
 
 type fileTreeMsg struct {
-	files    []*gitdiff.File
-	preamble string
+	files      []*gitdiff.File
+	preamble   string
+	collapsed  map[string]bool
+	excluded   int
+	excludedBy []string
+	owners     map[string][]string
 }
 
 func (m mainModel) fetchFileTree() tea.Msg {
@@ -474,34 +703,120 @@ func (m mainModel) fetchFileTree() tea.Msg {
 	if err != nil {
 		return common.ErrMsg{Err: err}
 	}
-	sortFiles(files)
+	// Drop excluded files and mark generated ones, which are shown in a
+	// collapsed group at the bottom of the tree.
+	matcher := exclude.Load(m.config, utils.GitTopLevel())
+	visible := make([]*gitdiff.File, 0, len(files))
+	collapsed := map[string]bool{}
+	for _, file := range files {
+		switch matcher.Classify(file) {
+		case exclude.Hide:
+			continue
+		case exclude.Collapse:
+			collapsed[filenode.GetFileName(file)] = true
+		}
+		visible = append(visible, file)
+	}
+	filesort.Sort(visible, m.sortMode, m.config.UI.ReviewOrder)
+
+	codeOwners := codeowners.Load(utils.GitTopLevel())
+	owners := map[string][]string{}
+	if codeOwners.HasRules() {
+		for _, file := range visible {
+			name := filenode.GetFileName(file)
+			owners[name] = codeOwners.Of(name)
+		}
+	}
+
+	return fileTreeMsg{
+		files:      visible,
+		preamble:   preamble,
+		collapsed:  collapsed,
+		excluded:   len(files) - len(visible),
+		excludedBy: matcher.Rules(),
+		owners:     owners,
+	}
+}
 
-	return fileTreeMsg{files: files, preamble: preamble}
+// allExcluded reports whether the diff has files, but all of them are
+// excluded.
+func (m mainModel) allExcluded() bool {
+	return len(m.files) == 0 && m.excluded > 0
+}
+
+// allExcludedView tells that every file is excluded, and by which rules.
+func (m mainModel) allExcludedView() string {
+	t := theme.Current()
+	lines := []string{fmt.Sprintf("All %d files of the diff are excluded", m.excluded)}
+	if len(m.excludedBy) > 0 {
+		lines[0] += " by:"
+		for _, rule := range m.excludedBy {
+			lines = append(lines, lipgloss.NewStyle().Foreground(t.Muted).Render("  "+rule))
+		}
+	}
+	lines = append(lines, "", "Press q to quit.")
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center,
+		lipgloss.JoinVertical(lipgloss.Left, lines...))
 }
 
 func (m mainModel) footerView() string {
-	base := lipgloss.NewStyle().Background(common.Colors[common.DarkerSelected])
+	t := theme.Current()
+	base := lipgloss.NewStyle().Background(t.Bar)
 	files := fmt.Sprintf(" %d files", len(m.files))
-	sep := lipgloss.NewStyle().Foreground(lipgloss.BrightBlack).Render(" • ")
+	if m.fileTree.HasStatusFilter() {
+		files = fmt.Sprintf(" %d/%d files", len(m.fileTree.VisibleFiles()), len(m.files))
+	}
+	if m.excluded > 0 {
+		files += base.Foreground(t.Muted).Render(fmt.Sprintf(" (%d excluded)", m.excluded))
+	}
+	sep := lipgloss.NewStyle().Foreground(t.Muted).Render(" • ")
 	added, deleted := m.diffViewer.RootDiffStats()
-	help := base.Background(lipgloss.BrightBlack).PaddingLeft(1).PaddingRight(1).Render("F1/? help")
+	help := base.Background(t.Muted).PaddingLeft(1).PaddingRight(1).Render("F1/? help")
 	stats := filenode.ViewDiffStats(added, deleted, base)
+	viewed := sep + base.Foreground(t.Muted).
+		Render(fmt.Sprintf("%d/%d viewed", m.viewedCount(), len(m.files)))
+	sort := ""
+	if m.sortMode != filesort.ModeTree {
+		sort = sep + base.Foreground(t.Muted).Render("sort: "+m.sortMode)
+	}
+	if preset := m.diffViewer.Preset(); preset != "" {
+		sort += sep + base.Foreground(t.Muted).Render("preset: "+preset)
+	}
+	if n := len(m.fileTree.SelectedFiles()); n > 0 {
+		sort += sep + base.Foreground(t.Marked).Render(fmt.Sprintf("%d selected", n))
+	}
+	if m.fileTree.GroupedByOwner() {
+		sort += sep + base.Foreground(t.Muted).Render("by owner")
+	}
+	if n := m.diffViewer.SelectedRows(); n > 0 {
+		sort += sep + base.Foreground(t.Accent).Render(fmt.Sprintf("visual: %d rows", n))
+	}
+	if keys := m.pendingKeys(); keys != "" {
+		sort += sep + base.Foreground(t.Accent).Render(keys)
+	}
+	if m.configErr {
+		sort += sep + base.Foreground(t.Deleted).Render("config error, see diffnav config validate")
+	}
+	if m.statusErr != nil {
+		sort += sep + base.Foreground(t.Deleted).Render(m.statusErr.Error())
+	}
 	spacing := base.Render(strings.Repeat(" ", max(0, m.width-lipgloss.Width(stats)-
-		lipgloss.Width(help)-lipgloss.Width(files)-lipgloss.Width(sep))))
+		lipgloss.Width(help)-lipgloss.Width(files)-lipgloss.Width(sep)-lipgloss.Width(viewed)-
+		lipgloss.Width(sort))))
 	return base.
 		Width(m.width).
 		Height(1).
-		Render(lipgloss.JoinHorizontal(lipgloss.Top, files, sep, stats, spacing, help))
+		Render(lipgloss.JoinHorizontal(lipgloss.Top, files, sep, stats, viewed, sort, spacing, help))
 }
 
 func (m mainModel) resultsView() string {
 	sb := strings.Builder{}
 	for i, f := range m.filtered {
-		fName := utils.TruncateString(" "+f, m.config.UI.SearchTreeWidth-2)
+		fName := utils.TruncateString(" "+f, m.sidebarWidth()-2)
 		if i == m.resultsCursor {
 			sb.WriteString(
 				lipgloss.NewStyle().
-					Background(lipgloss.Color("#1b1b33")).
+					Background(theme.Current().Cursor).
 					Bold(true).
 					Render(fName) +
 					"\n",
@@ -513,13 +828,65 @@ func (m mainModel) resultsView() string {
 	return sb.String()
 }
 
+// filterBarView renders the status filter bar shown above the file tree.
+// Hidden statuses are struck through.
+func (m mainModel) filterBarView() string {
+	dim := lipgloss.NewStyle().Foreground(theme.Current().Muted)
+	prefix := dim.Render(" filter ")
+	if m.filtering {
+		prefix = lipgloss.NewStyle().Foreground(theme.Current().Accent).Bold(true).Render(" filter ")
+	}
+
+	labels := make([]string, 0, len(filenode.Statuses))
+	for _, status := range filenode.Statuses {
+		label := status.String()[:1]
+		if m.fileTree.IsStatusHidden(status) {
+			labels = append(labels, dim.Strikethrough(true).Render(label))
+		} else {
+			labels = append(labels, lipgloss.NewStyle().
+				Foreground(filenode.StatusColor(status)).
+				Render(label))
+		}
+	}
+
+	w := m.sidebarWidth()
+	return lipgloss.NewStyle().
+		Width(w).
+		MaxWidth(w).
+		Render(prefix + strings.Join(labels, " "))
+}
+
+func (m mainModel) isFilterBarVisible() bool {
+	return m.filtering || m.fileTree.HasStatusFilter()
+}
+
+// fileTreeHeight returns the height available to the file tree below the
+// search box and the filter bar.
+func (m mainModel) fileTreeHeight() int {
+	h := m.sidebarHeight() - searchHeight
+	if m.isFilterBarVisible() {
+		h -= filterBarHeight
+	}
+	return h
+}
+
+// outlineHeight returns the height of the symbol outline, which replaces the
+// filter bar and the file tree.
+func (m mainModel) outlineHeight() int {
+	return m.sidebarHeight() - searchHeight
+}
+
 func (m mainModel) sidebarWidth() int {
+	if m.isStacked() && m.isSidebarVisible() {
+		return m.width
+	}
+
 	if m.searching {
 		return m.config.UI.SearchTreeWidth
 	}
 
 	if m.isShowingFileTree {
-		return m.fileTree.Width()
+		return m.treeWidth
 	}
 
 	return 0
@@ -550,21 +917,72 @@ func (m *mainModel) stopSearch() {
 	m.search.SetWidth(m.searchWidth())
 }
 
+// openInEditor opens the selected files, or else the node under the cursor
+// at its cursor position, with the configured editor. Paths are resolved from
+// the root of the repo.
 func (m mainModel) openInEditor() tea.Cmd {
 	if len(m.files) == 0 {
 		return nil
 	}
 
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		return nil
+	closed := func(err error) tea.Msg {
+		return editorClosedMsg{err: err}
+	}
+	tmpl, err := editor.Template(m.config.Editor.Command, m.config.Editor.Preset, os.Getenv("EDITOR"))
+	if err != nil {
+		return func() tea.Msg { return closed(err) }
 	}
 
-	fullpath := m.fileTree.CurrNodePath()
-	c := exec.Command(editor, fullpath)
-	return tea.ExecProcess(c, func(err error) tea.Msg {
-		return nil
-	})
+	root := utils.GitTopLevel()
+	loc := editor.Location{Path: m.fileTree.CurrNodePath()}
+	loc.Line, loc.Column = m.cursorPosition()
+	var more []string
+	if selected := m.fileTree.SelectedFiles(); len(selected) > 0 {
+		loc = editor.Location{Path: filenode.GetFileName(selected[0]), Line: 1, Column: 1}
+		for _, file := range selected[1:] {
+			more = append(more, filepath.Join(root, filenode.GetFileName(file)))
+		}
+	}
+	loc.Path = filepath.Join(root, loc.Path)
+
+	args, err := editor.Command(tmpl, loc, more...)
+	if err != nil {
+		return func() tea.Msg { return closed(err) }
+	}
+	return tea.ExecProcess(exec.Command(args[0], args[1:]...), closed)
+}
+
+// cursorPosition returns the line and column of the new file to open the
+// node under the cursor at: the top line of the diff when it's focused, or
+// else the first change of the hunk, or of the file.
+func (m mainModel) cursorPosition() (int, int) {
+	node := m.fileTree.GetCurrNode()
+	if node == nil {
+		return 1, 1
+	}
+	var file *gitdiff.File
+	var frag *gitdiff.TextFragment
+	switch val := node.GivenValue().(type) {
+	case *hunknode.HunkNode:
+		file, frag = val.File, val.Fragment
+	case *filenode.FileNode:
+		file = val.File
+		if len(file.TextFragments) > 0 {
+			frag = file.TextFragments[0]
+		}
+	default:
+		return 1, 1
+	}
+
+	if m.activePanel == DiffViewerPanel {
+		if shown, line, column := m.diffViewer.TopLine(); shown == file && line > 0 {
+			return line, column
+		}
+	}
+	if frag != nil {
+		return hunknode.FirstChange(frag)
+	}
+	return 1, 1
 }
 
 func (m mainModel) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
@@ -577,16 +995,15 @@ func (m mainModel) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	case tea.MouseClickMsg:
 		if msg.Button == tea.MouseLeft {
 			// Keep coordinate check for resize border (hybrid approach).
-			sidebarWidth := m.sidebarWidth()
-			if !m.searching && m.isShowingFileTree && abs(msg.X-sidebarWidth) <= sidebarGrabThreshold {
+			if !m.searching && m.isShowingFileTree && m.onSidebarBorder(msg.X, msg.Y) {
 				m.draggingSidebar = true
 				return m, nil
 			}
 			// Allow grabbing the line when sidebar is hidden.
-			if !m.isSidebarVisible() && msg.X <= sidebarGrabThreshold {
+			if !m.isSidebarVisible() && m.onSidebarBorder(msg.X, msg.Y) {
 				m.draggingSidebar = true
 				m.isShowingFileTree = true
-				return m, nil
+				return m, m.resize()
 			}
 
 			// Zone-based detection for everything else.
@@ -599,15 +1016,22 @@ func (m mainModel) handleMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 			if !m.searching && zone.Get(zoneFileTree).InBounds(msg) {
 				return m.handleFileTreeClick(msg)
 			}
+			if zone.Get(zoneDiffViewer).InBounds(msg) {
+				return m.handleDiffClick(msg)
+			}
 		}
 
 	case tea.MouseReleaseMsg:
 		m.draggingSidebar = false
+		m.draggingDiff = false
 
 	case tea.MouseMotionMsg:
 		if m.draggingSidebar {
 			return m.handleSidebarDrag(msg)
 		}
+		if m.draggingDiff {
+			return m.handleDiffDrag(msg)
+		}
 	}
 
 	return m, nil
@@ -630,7 +1054,7 @@ func (m mainModel) handleSearchResultClick(msg tea.MouseMsg) (tea.Model, tea.Cmd
 
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
-	dfCmd := m.diffViewer.SetSize(m.width-m.sidebarWidth(), m.mainContentHeight())
+	dfCmd := m.diffViewer.SetSize(m.diffWidth(), m.diffHeight())
 	cmds = append(cmds, dfCmd)
 
 	for _, f := range m.files {
@@ -655,11 +1079,11 @@ func (m mainModel) handleSearchBoxClick() (tea.Model, tea.Cmd) {
 	m.resultsCursor = 0
 	m.setSearchResults()
 
-	m.resultsVp.SetWidth(m.config.UI.SearchTreeWidth)
-	m.resultsVp.SetHeight(m.mainContentHeight() - searchHeight)
+	m.resultsVp.SetWidth(m.sidebarWidth())
+	m.resultsVp.SetHeight(m.sidebarHeight() - searchHeight)
 	m.resultsVp.SetContent(m.resultsView())
 
-	dfCmd := m.diffViewer.SetSize(m.width-m.sidebarWidth(), m.mainContentHeight())
+	dfCmd := m.diffViewer.SetSize(m.diffWidth(), m.diffHeight())
 	return m, tea.Batch(dfCmd, m.search.Focus())
 }
 
@@ -721,18 +1145,28 @@ func (m mainModel) handleSidebarDrag(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.isStacked() {
+		return m.handleStackedSidebarDrag(msg)
+	}
+
+	// The sidebar's size is the distance from the edge of the screen it's on.
+	size := msg.Mouse().X
+	if m.layout() == LayoutRight {
+		size = m.width - 1 - msg.Mouse().X
+	}
+
 	// Hide sidebar if dragged below threshold.
-	if msg.Mouse().X < sidebarHideWidth {
+	if size < sidebarHideWidth {
 		m.isShowingFileTree = false
 		m.draggingSidebar = false
-		cmd := m.diffViewer.SetSize(m.width, m.mainContentHeight())
-		return m, cmd
+		m.activePanel = DiffViewerPanel
+		return m, m.resize()
 	}
 
 	// Clamp to reasonable bounds.
 	minWidth := sidebarMinWidth
 	maxWidth := m.width / 2
-	newWidth := max(minWidth, min(maxWidth, msg.Mouse().X))
+	newWidth := max(minWidth, min(maxWidth, size))
 
 	// TODO: for some reason setting a value smaller than minResizeStep
 	// will garble up the output when resizing. I have no idea why.
@@ -740,13 +1174,26 @@ func (m mainModel) handleSidebarDrag(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Resize components.
-	cmds := []tea.Cmd{}
+	m.treeWidth = newWidth
+	return m, m.resize()
+}
 
-	cmds = append(cmds, m.diffViewer.SetSize(m.width-newWidth, m.mainContentHeight()))
-	m.fileTree.SetSize(newWidth-1, m.mainContentHeight()-searchHeight-1)
+// handleStackedSidebarDrag resizes the sidebar stacked above the diff.
+func (m mainModel) handleStackedSidebarDrag(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	height := msg.Mouse().Y - m.contentTop()
+	if height < stackedMinHeight {
+		m.isShowingFileTree = false
+		m.draggingSidebar = false
+		m.activePanel = DiffViewerPanel
+		return m, m.resize()
+	}
 
-	return m, tea.Batch(cmds...)
+	newHeight := min(height, m.mainContentHeight()-stackedMinDiffHeight)
+	if newHeight == m.sidebarHeight() {
+		return m, nil
+	}
+	m.treeHeight = newHeight
+	return m, m.resize()
 }
 
 func abs(x int) int {
@@ -759,11 +1206,15 @@ func abs(x int) int {
 func (m mainModel) moveToFile(movement int) (mainModel, tea.Cmd) {
 	var cmd tea.Cmd
 	var moved bool
-	switch movement {
-	case -1:
-		moved = m.fileTree.PrevFile()
-	case 1:
-		moved = m.fileTree.NextFile()
+	for i := 0; i < abs(movement); i++ {
+		step := m.fileTree.NextFile
+		if movement < 0 {
+			step = m.fileTree.PrevFile
+		}
+		if !step() {
+			break
+		}
+		moved = true
 	}
 
 	if !moved {
@@ -779,16 +1230,14 @@ func (m mainModel) moveToFile(movement int) (mainModel, tea.Cmd) {
 
 func (m mainModel) moveCursor(movement int) (mainModel, tea.Cmd) {
 	var cmd tea.Cmd
-	switch movement {
-	case -1:
-		m.fileTree.Up()
-	case 1:
-		m.fileTree.Down()
-	}
+	m.fileTree.MoveCursor(movement)
 
 	node := m.fileTree.GetCurrNode()
 	m, cmd = m.setNodeDiff(node)
-	m.diffViewer.GoToTop()
+	// Hunks scroll the diff to themselves.
+	if _, ok := node.GivenValue().(*hunknode.HunkNode); !ok {
+		m.diffViewer.GoToTop()
+	}
 
 	return m, cmd
 }
@@ -798,12 +1247,14 @@ func (m mainModel) setNodeDiff(node *tree.Node) (mainModel, tea.Cmd) {
 	switch val := node.GivenValue().(type) {
 	case *filenode.FileNode:
 		m.diffViewer, cmd = m.diffViewer.SetFilePatch(val.File)
+	case *hunknode.HunkNode:
+		m.diffViewer, cmd = m.diffViewer.SetHunkPatch(val.File, val.Index)
 	case string, *dirnode.DirNode:
 		files := m.fileTree.GetCurrNodeDesendantDiffs()
 
 		fullPath := "/"
 		if val, ok := node.GivenValue().(*dirnode.DirNode); ok {
-			fullPath = val.FullPath
+			fullPath = val.DiffPath()
 		}
 		m.diffViewer, cmd = m.diffViewer.SetDirPatch(fullPath, files)
 	}
@@ -812,13 +1263,22 @@ func (m mainModel) setNodeDiff(node *tree.Node) (mainModel, tea.Cmd) {
 }
 
 func (m *mainModel) setSearchResults() {
+	query, ownerQueries := parseSearchQuery(m.search.Value())
 	filtered := make([]string, 0)
-	for _, f := range m.files {
-		if strings.Contains(
-			strings.ToLower(filenode.GetFileName(f)),
-			strings.ToLower(m.search.Value()),
-		) {
-			filtered = append(filtered, filenode.GetFileName(f))
+	for _, f := range m.fileTree.VisibleFiles() {
+		name := filenode.GetFileName(f)
+		if !strings.Contains(strings.ToLower(name), strings.ToLower(query)) {
+			continue
+		}
+		matchesOwners := true
+		for _, owner := range ownerQueries {
+			if !codeowners.MatchesOwner(m.owners[name], owner) {
+				matchesOwners = false
+				break
+			}
+		}
+		if matchesOwners {
+			filtered = append(filtered, name)
 		}
 	}
 	m.filtered = filtered
@@ -832,6 +1292,28 @@ func (m *mainModel) setSearchResults() {
 	}
 }
 
+// parseSearchQuery splits the "owner:" filters, e.g. "owner:@org/team", out
+// of a search query. The rest of the query is matched against file paths.
+func parseSearchQuery(value string) (string, []string) {
+	var owners []string
+	var rest []string
+	for _, field := range strings.Fields(value) {
+		if owner, ok := strings.CutPrefix(field, ownerSearchPrefix); ok {
+			if owner != "" {
+				owners = append(owners, owner)
+			}
+			continue
+		}
+		rest = append(rest, field)
+	}
+	query := strings.Join(rest, " ")
+	if len(owners) == 0 {
+		// keep the query as typed, including its spaces
+		query = value
+	}
+	return query, owners
+}
+
 func (m mainModel) selectedSearchResult() (string, bool) {
 	if len(m.filtered) == 0 {
 		return "", false
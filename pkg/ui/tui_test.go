@@ -1,15 +1,22 @@
 package ui
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
 	tea "charm.land/bubbletea/v2"
 	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	"github.com/charmbracelet/x/ansi"
 	zone "github.com/lrstanley/bubblezone/v2"
 
 	"github.com/dlvhdr/diffnav/pkg/config"
+	"github.com/dlvhdr/diffnav/pkg/filenode"
+	"github.com/dlvhdr/diffnav/pkg/theme"
+	"github.com/dlvhdr/diffnav/pkg/ui/panes/symbols"
 )
 
 func TestSearchUpdateEnterWithNoResultsDoesNotPanic(t *testing.T) {
@@ -174,12 +181,165 @@ func TestSearchSidebarDragMotionIsIgnored(t *testing.T) {
 	}
 }
 
+func TestStatusFilterHidesFilesFromTreeAndSearch(t *testing.T) {
+	m := newTestMainModel(t)
+	m.width = 100
+	m.height = 40
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "f", Code: 'f'}))
+	if !m.filtering {
+		t.Fatal("expected f to focus the filter bar")
+	}
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "m", Code: 'm'}))
+	if got := len(m.fileTree.VisibleFiles()); got != 0 {
+		t.Fatalf("expected modified files to be hidden, got %d visible", got)
+	}
+	m.setSearchResults()
+	if len(m.filtered) != 0 {
+		t.Fatalf("expected hidden files to be excluded from search, got %v", m.filtered)
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+	if m.filtering {
+		t.Fatal("expected esc to close the filter bar")
+	}
+	if !m.isFilterBarVisible() {
+		t.Fatal("expected the filter bar to stay visible while a filter is active")
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "f", Code: 'f'}))
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "m", Code: 'm'}))
+	if got := len(m.fileTree.VisibleFiles()); got != 2 {
+		t.Fatalf("expected all files to be visible again, got %d", got)
+	}
+	_ = m.View().Content
+}
+
+func TestToggleViewedMarksDirectoryFiles(t *testing.T) {
+	t.Setenv("DIFFNAV_STATE_DIR", t.TempDir())
+	m := newTestMainModel(t)
+	m.width = 100
+	m.height = 40
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "m", Code: 'm'}))
+	if got := m.viewedCount(); got != 2 {
+		t.Fatalf("expected all files under the root to be viewed, got %d", got)
+	}
+	if !m.viewed.IsViewed(m.files[0]) {
+		t.Fatal("expected the viewed mark to be stored")
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "m", Code: 'm'}))
+	if got := m.viewedCount(); got != 0 {
+		t.Fatalf("expected all files to be un-marked, got %d viewed", got)
+	}
+}
+
+func TestOwnerSearchAndGrouping(t *testing.T) {
+	m := newTestMainModel(t)
+	m.width = 100
+	m.height = 40
+	m.owners = map[string][]string{
+		"graphql-server/tests/package.json": {"@org/server"},
+		"yarn.lock":                         {"@org/deps"},
+	}
+	m.fileTree.SetOwners(m.owners)
+
+	m.search.SetValue("owner:@org/server")
+	m.setSearchResults()
+	if len(m.filtered) != 1 || m.filtered[0] != "graphql-server/tests/package.json" {
+		t.Fatalf("expected only the server file, got %v", m.filtered)
+	}
+	m.search.SetValue("owner:@org/server yarn")
+	m.setSearchResults()
+	if len(m.filtered) != 0 {
+		t.Fatalf("expected owner and path filters to combine, got %v", m.filtered)
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "O", Code: 'O'}))
+	if !m.fileTree.GroupedByOwner() {
+		t.Fatal("expected O to group the tree by owner")
+	}
+	_ = m.View().Content
+}
+
+func TestOutlineJumpsToSymbol(t *testing.T) {
+	zone.NewGlobal()
+	data, err := os.ReadFile("../../examples/gh_dash_pr.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(strings.NewReader(string(data) + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(string(data), config.DefaultConfig())
+	m.files = files
+	m.fileTree = m.fileTree.SetFiles(files)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	updated, cmd := m.Update(tea.KeyPressMsg(tea.Key{Text: "T", Code: 'T'}))
+	m = updated.(mainModel)
+	if !m.outlineOpen || cmd == nil {
+		t.Fatal("expected T to open the outline and load its symbols")
+	}
+	// Without the repository's blobs, symbols are found in the hunk text.
+	files = m.fileTree.GetCurrNodeDesendantDiffs()
+	stale := symbols.Load(files, t.TempDir(), m.outline.Gen()-1)()
+	m = updateMainModel(t, m, stale)
+	if _, ok := m.outline.Selected(); ok {
+		t.Fatal("expected the symbols of a previous outline to be dropped")
+	}
+	m = updateMainModel(t, m, symbols.Load(files, t.TempDir(), m.outline.Gen())())
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyDown}))
+	entry, ok := m.outline.Selected()
+	if !ok {
+		t.Fatal("expected symbols in the outline")
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if m.outlineOpen {
+		t.Fatal("expected enter to close the outline")
+	}
+	if path := m.fileTree.CurrNodePath(); path != filenode.GetFileName(entry.File) {
+		t.Fatalf("expected the tree cursor on %q, got %q", filenode.GetFileName(entry.File), path)
+	}
+	_ = m.View().Content
+}
+
+func TestSelectedFilesCombinedDiff(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "n", Code: 'n'}))
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeySpace, Text: " "}))
+	selected := m.fileTree.SelectedFiles()
+	if len(selected) != 1 || filenode.GetFileName(selected[0]) != m.fileTree.CurrNodePath() {
+		t.Fatalf("expected space to select the file under the cursor, got %d files", len(selected))
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "c", Code: 'c'}))
+	if !strings.Contains(ansi.Strip(m.diffViewer.View()), "1 selected file") {
+		t.Fatalf("expected c to show the combined diff of the selection, got:\n%s", ansi.Strip(m.diffViewer.View()))
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "C", Code: 'C'}))
+	if got := len(m.fileTree.SelectedFiles()); got != 0 {
+		t.Fatalf("expected C to clear the selection, got %d files", got)
+	}
+}
+
 func newTestMainModel(t *testing.T) mainModel {
+	t.Helper()
+	return newTestMainModelFrom(t, "../../examples/multiple_files.txt")
+}
+
+func newTestMainModelFrom(t *testing.T, path string) mainModel {
 	t.Helper()
 	zone.NewGlobal()
 
 	cfg := config.DefaultConfig()
-	data, err := os.ReadFile("../../examples/multiple_files.txt")
+	data, err := os.ReadFile(path)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -207,3 +367,588 @@ func updateMainModel(t *testing.T, m mainModel, msg tea.Msg) mainModel {
 
 	return result
 }
+
+// showDiff focuses the diff and shows the longest file of the diff in it,
+// rendered by a delta that prints the patch as is.
+func showDiff(t *testing.T, m mainModel) mainModel {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "delta"), []byte("#!/bin/sh\ncat\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	file := slices.MaxFunc(m.files, func(a, b *gitdiff.File) int {
+		return len(a.String()) - len(b.String())
+	})
+	var cmd tea.Cmd
+	m.diffViewer, cmd = m.diffViewer.SetFilePatch(file)
+	m = updateMainModel(t, m, cmd())
+	m.activePanel = DiffViewerPanel
+	return m
+}
+
+func TestConfigReloadAppliesChanges(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "j", Code: 'j'}))
+	path := m.fileTree.CurrNodePath()
+
+	cfg := config.DefaultConfig()
+	cfg.UI.Icons = filenode.IconsASCII
+	cfg.UI.FileTreeWidth = 40
+	cfg.UI.HideFooter = true
+	m = updateMainModel(t, m, configReloadedMsg{cfg: cfg, theme: theme.Current()})
+
+	if m.iconStyle != filenode.IconsASCII {
+		t.Fatalf("expected the icon style to be reloaded, got %q", m.iconStyle)
+	}
+	if got := m.fileTree.Width(); got != 40 {
+		t.Fatalf("expected the file tree to be resized to 40, got %d", got)
+	}
+	if got := m.diffViewer.Height; got != m.mainContentHeight() || m.footerHeight() != 0 {
+		t.Fatalf("expected the diff viewer to take the footer's space, got height %d", got)
+	}
+	if got := m.fileTree.CurrNodePath(); got != path {
+		t.Fatalf("expected the cursor to stay on %q, got %q", path, got)
+	}
+}
+
+func TestConfigReloadKeepsSessionToggles(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "i", Code: 'i'}))
+	iconStyle := m.iconStyle
+
+	cfg := config.DefaultConfig()
+	cfg.UI.ShowDiffStats = !cfg.UI.ShowDiffStats
+	m = updateMainModel(t, m, configReloadedMsg{cfg: cfg, theme: theme.Current()})
+	if m.iconStyle != iconStyle {
+		t.Fatalf("expected the icon style toggled in the session to be kept, got %q", m.iconStyle)
+	}
+
+	m = updateMainModel(t, m, configReloadedMsg{cfg: m.config, err: errors.New("invalid")})
+	if !m.configErr || !strings.Contains(ansi.Strip(m.footerView()), "config error") {
+		t.Fatal("expected a failed reload to be reported in the footer")
+	}
+}
+
+func TestConfigWatcherDetectsChanges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	w := &configWatcher{paths: []string{path}}
+	w.stamps = modTimes(w.paths)
+
+	if w.changed(modTimes(w.paths)) {
+		t.Fatal("expected no change before the file is created")
+	}
+	if err := os.WriteFile(path, []byte("ui:\n  hideHeader: true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !w.changed(modTimes(w.paths)) {
+		t.Fatal("expected the created file to be detected")
+	}
+	if w.changed(modTimes(w.paths)) {
+		t.Fatal("expected no change once the new times are remembered")
+	}
+}
+
+func TestAutoLayoutStacksInNarrowTerminals(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 60, Height: 40})
+	if m.layout() != LayoutLeft {
+		t.Fatalf("expected the left layout by default, got %q", m.layout())
+	}
+
+	m.config.UI.Layout = LayoutAuto
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	if m.layout() != LayoutLeft {
+		t.Fatalf("expected the left layout in a wide terminal, got %q", m.layout())
+	}
+
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 60, Height: 40})
+	if m.layout() != LayoutTop {
+		t.Fatalf("expected the top layout in a narrow terminal, got %q", m.layout())
+	}
+	if m.diffViewer.Width != 60 {
+		t.Fatalf("expected the diff to take the full width, got %d", m.diffViewer.Width)
+	}
+	if got, want := m.diffViewer.Height, m.mainContentHeight()-m.config.UI.FileTreeHeight-1; got != want {
+		t.Fatalf("expected the diff to be %d rows below the file tree, got %d", want, got)
+	}
+	if got := m.fileTree.Width(); got != 60 {
+		t.Fatalf("expected the file tree to take the full width, got %d", got)
+	}
+}
+
+func TestRightLayoutDragResizesSidebar(t *testing.T) {
+	m := newTestMainModel(t)
+	m.config.UI.Layout = LayoutRight
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	border := 100 - m.sidebarWidth() - 1
+	m = updateMainModel(t, m, tea.MouseClickMsg(tea.Mouse{X: border, Y: 10, Button: tea.MouseLeft}))
+	if !m.draggingSidebar {
+		t.Fatal("expected a click on the sidebar's left border to start dragging")
+	}
+	m = updateMainModel(t, m, tea.MouseMotionMsg(tea.Mouse{X: 59, Y: 10}))
+	if got := m.sidebarWidth(); got != 40 {
+		t.Fatalf("expected dragging to x=59 to make the sidebar 40 wide, got %d", got)
+	}
+	if got := m.diffViewer.Width; got != 59 {
+		t.Fatalf("expected the diff to fill the rest, got %d", got)
+	}
+
+	m = updateMainModel(t, m, tea.MouseMotionMsg(tea.Mouse{X: 95, Y: 10}))
+	if m.isShowingFileTree {
+		t.Fatal("expected dragging to the right edge to hide the sidebar")
+	}
+}
+
+func TestTopLayoutDragResizesSidebarVertically(t *testing.T) {
+	m := newTestMainModel(t)
+	m.config.UI.Layout = LayoutTop
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	border := m.contentTop() + m.sidebarHeight()
+	m = updateMainModel(t, m, tea.MouseClickMsg(tea.Mouse{X: 50, Y: border, Button: tea.MouseLeft}))
+	if !m.draggingSidebar {
+		t.Fatal("expected a click on the sidebar's bottom border to start dragging")
+	}
+	m = updateMainModel(t, m, tea.MouseMotionMsg(tea.Mouse{X: 50, Y: m.contentTop() + 20}))
+	if got := m.sidebarHeight(); got != 20 {
+		t.Fatalf("expected the sidebar to be 20 rows high, got %d", got)
+	}
+	if got, want := m.fileTreeHeight(), 20-searchHeight; got != want {
+		t.Fatalf("expected the file tree to be %d rows high, got %d", want, got)
+	}
+
+	m = updateMainModel(t, m, tea.MouseMotionMsg(tea.Mouse{X: 50, Y: m.contentTop() + 2}))
+	if m.isShowingFileTree {
+		t.Fatal("expected dragging to the top to hide the sidebar")
+	}
+	if got := m.diffViewer.Height; got != m.mainContentHeight() {
+		t.Fatalf("expected the diff to take the full height, got %d", got)
+	}
+}
+
+func TestAutoSideBySideFollowsWidth(t *testing.T) {
+	m := newTestMainModel(t)
+	m.config.UI.AutoSideBySide = true
+	m.config.UI.AutoSideBySideWidth = 100
+	m.diffViewer.SetAutoSideBySide(m.config.UI.AutoSideBySideWidth)
+
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 160, Height: 40})
+	if !m.diffViewer.SideBySide() {
+		t.Fatal("expected side-by-side in a wide terminal")
+	}
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 120, Height: 40})
+	if m.diffViewer.SideBySide() {
+		t.Fatal("expected unified once the diff is narrower than the threshold")
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "s", Code: 's'}))
+	if !m.diffViewer.SideBySide() {
+		t.Fatal("expected s to switch to side-by-side")
+	}
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 110, Height: 40})
+	if !m.diffViewer.SideBySide() {
+		t.Fatal("expected the view mode picked with s to turn the auto mode off")
+	}
+
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "s", Code: 's'}))
+	if m.diffViewer.SideBySide() {
+		t.Fatal("expected s to switch to unified")
+	}
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: "s", Code: 's'}))
+	if !m.diffViewer.AutoSideBySide() {
+		t.Fatal("expected s to switch back to the auto mode")
+	}
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 160, Height: 40})
+	if !m.diffViewer.SideBySide() {
+		t.Fatal("expected side-by-side in a wide terminal once back in the auto mode")
+	}
+}
+
+func TestMotionCounts(t *testing.T) {
+	m := newTestMainModelFrom(t, "../../examples/gh_dash_pr.txt")
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	typeKeys := func(m mainModel, keys string) mainModel {
+		for _, r := range keys {
+			m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: string(r), Code: r}))
+		}
+		return m
+	}
+
+	// The files in the order n goes through them.
+	m = typeKeys(m, "gg")
+	files := []string{m.fileTree.CurrNodePath()}
+	for {
+		m = typeKeys(m, "n")
+		path := m.fileTree.CurrNodePath()
+		if path == files[len(files)-1] {
+			break
+		}
+		files = append(files, path)
+	}
+	if len(files) != 6 {
+		t.Fatalf("expected to go through 6 files, got %v", files)
+	}
+
+	m = typeKeys(m, "gg")
+	if got := m.fileTree.CurrNodePath(); got != files[0] {
+		t.Fatalf("expected gg to go to the first file, got %q", got)
+	}
+	m = typeKeys(m, "3n")
+	if got := m.fileTree.CurrNodePath(); got != files[3] {
+		t.Fatalf("expected 3n to skip 3 files, got %q", got)
+	}
+	m = typeKeys(m, "2p")
+	if got := m.fileTree.CurrNodePath(); got != files[1] {
+		t.Fatalf("expected 2p to go back 2 files, got %q", got)
+	}
+	m = typeKeys(m, "G")
+	if got := m.fileTree.CurrNodePath(); got != files[5] {
+		t.Fatalf("expected G to go to the last file, got %q", got)
+	}
+	m = typeKeys(m, "5gg")
+	if got := m.fileTree.CurrNodePath(); got != files[4] {
+		t.Fatalf("expected 5gg to go to the fifth file, got %q", got)
+	}
+	m = typeKeys(m, "10G")
+	if got := m.fileTree.CurrNodePath(); got != files[5] {
+		t.Fatalf("expected a count past the last file to go to it, got %q", got)
+	}
+
+	m = typeKeys(m, "12g")
+	if got := m.pendingKeys(); got != "12g" {
+		t.Fatalf("expected the footer to show the pending motion, got %q", got)
+	}
+	m = typeKeys(m, "m")
+	if m.count != 0 || m.pending != nil {
+		t.Fatal("expected another key to cancel the pending motion")
+	}
+	if !m.fileTree.IsViewed(files[5]) {
+		t.Fatal("expected the key cancelling the motion to be handled")
+	}
+}
+
+func TestCommandPalette(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	typeKeys := func(m mainModel, keys string) mainModel {
+		for _, r := range keys {
+			m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: string(r), Code: r}))
+		}
+		return m
+	}
+	enter := tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter})
+
+	m = typeKeys(m, ":sort churn")
+	m = updateMainModel(t, m, enter)
+	if m.paletteOpen || m.sortMode != "churn" {
+		t.Fatalf("expected :sort churn to set the sort mode, got %q", m.sortMode)
+	}
+
+	m = typeKeys(m, ":sort nope")
+	m = updateMainModel(t, m, enter)
+	if !m.paletteOpen || m.sortMode != "churn" {
+		t.Fatal("expected an invalid command to keep the palette open")
+	}
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEscape}))
+
+	m = typeKeys(m, ":goto yarn.lock")
+	m = updateMainModel(t, m, enter)
+	if got := m.fileTree.CurrNodePath(); got != "yarn.lock" {
+		t.Fatalf("expected :goto to go to the file, got %q", got)
+	}
+
+	m = typeKeys(m, ":toggleViewed")
+	m = updateMainModel(t, m, enter)
+	if !m.fileTree.IsViewed("yarn.lock") {
+		t.Fatal("expected the palette to run the action of the key map")
+	}
+	// Two-key motions run at once, with the count as their argument.
+	m = typeKeys(m, ":goToTop 2")
+	m = updateMainModel(t, m, enter)
+	if got, want := m.fileTree.CurrNodePath(), filenode.GetFileName(m.fileTree.VisibleFiles()[1]); got != want {
+		t.Fatalf("expected :goToTop 2 to go to the second file %q, got %q", want, got)
+	}
+}
+
+func TestKeyPressMatchesBindings(t *testing.T) {
+	k, _ := NewKeyMap(nil)
+	for _, a := range k.actions() {
+		for _, name := range a.binding.Keys() {
+			if got := keyPress(name).String(); got != name {
+				t.Errorf("%s: expected a press of %q, got %q", a.Name, name, got)
+			}
+		}
+	}
+}
+
+func TestJumpHistory(t *testing.T) {
+	m := newTestMainModelFrom(t, "../../examples/gh_dash_pr.txt")
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	typeKeys := func(m mainModel, keys ...string) mainModel {
+		for _, k := range keys {
+			m = updateMainModel(t, m, keyPress(k))
+		}
+		return m
+	}
+
+	m = typeKeys(m, "g", "g")
+	first := m.fileTree.CurrNodePath()
+	m = typeKeys(m, "n")
+	second := m.fileTree.CurrNodePath()
+	m = typeKeys(m, "G")
+	last := m.fileTree.CurrNodePath()
+
+	for _, step := range []struct {
+		key  string
+		want string
+	}{
+		{"ctrl+o", second},
+		{"ctrl+o", first},
+		{"ctrl+i", second},
+		{"ctrl+i", last},
+		{"ctrl+i", last},
+	} {
+		m = typeKeys(m, step.key)
+		if got := m.fileTree.CurrNodePath(); got != step.want {
+			t.Fatalf("expected %s to go to %q, got %q", step.key, step.want, got)
+		}
+	}
+
+	// Going back and then elsewhere lists the location left last.
+	m = typeKeys(m, "ctrl+o", "ctrl+o", "k", "ctrl+o")
+	if got := m.fileTree.CurrNodePath(); got != first {
+		t.Fatalf("expected ctrl+o to go back to %q, got %q", first, got)
+	}
+	for i, a := range m.jumps.jumps {
+		for _, b := range m.jumps.jumps[i+1:] {
+			if a.sameNode(b) {
+				t.Fatalf("expected locations to be listed once, %q is listed twice", a.path)
+			}
+		}
+	}
+}
+
+func TestSelectLinesNeedsARenderedFileDiff(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	// No file diff is rendered, as there is no delta in tests.
+	m = updateMainModel(t, m, keyPress("v"))
+	if m.diffViewer.Selecting() || m.statusErr == nil {
+		t.Fatal("expected selecting lines without a file diff to fail")
+	}
+	if footer := ansi.Strip(m.footerView()); !strings.Contains(footer, m.statusErr.Error()) {
+		t.Fatalf("expected the footer to show the error, got %q", footer)
+	}
+
+	m = updateMainModel(t, m, keyPress("j"))
+	if m.statusErr != nil {
+		t.Fatal("expected the next key to clear the error")
+	}
+}
+
+func TestExportPatch(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	path := filepath.Join(t.TempDir(), "node.patch")
+	m = updateMainModel(t, m, keyPress("W"))
+	for _, r := range path {
+		m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Text: string(r), Code: r}))
+	}
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if m.paletteOpen {
+		t.Fatalf("expected the export to succeed, got %v", m.palette.View())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _, err := gitdiff.Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := m.fileTree.GetCurrNodeDesendantDiffs()
+	if len(files) == 0 || len(files) != len(want) {
+		t.Fatalf("expected the patch of the %d files under the cursor, got %d", len(want), len(files))
+	}
+	for i, file := range files {
+		if file.String() != want[i].String() {
+			t.Fatalf("expected %s to round-trip, got:\n%s", filenode.GetFileName(want[i]), file)
+		}
+	}
+
+	m = updateMainModel(t, m, keyPress("W"))
+	m = updateMainModel(t, m, tea.KeyPressMsg(tea.Key{Code: tea.KeyEnter}))
+	if !m.paletteOpen {
+		t.Fatal("expected exporting without a path to keep the palette open")
+	}
+}
+
+func TestExportPatchLeavesOutBinaryFiles(t *testing.T) {
+	diff := filepath.Join(t.TempDir(), "binary.diff")
+	if err := os.WriteFile(diff, []byte(`diff --git a/logo.png b/logo.png
+index 1234567..89abcde 100644
+Binary files a/logo.png and b/logo.png differ
+diff --git a/main.go b/main.go
+index 1234567..89abcde 100644
+--- a/main.go
++++ b/main.go
+@@ -1 +1 @@
+-package main
++package other
+`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestMainModelFrom(t, diff)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	m, err := m.exportPatch("~/node.patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(home, "node.patch"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "logo.png") || !strings.Contains(string(data), "+package other") {
+		t.Fatalf("expected only the text file in the patch, got:\n%s", data)
+	}
+	if m.statusErr == nil || !strings.Contains(m.statusErr.Error(), "logo.png") {
+		t.Fatalf("expected the binary file to be reported, got %v", m.statusErr)
+	}
+
+	if _, err := m.exportPatch("~/node.patch"); err == nil {
+		t.Fatal("expected an existing file not to be overwritten")
+	}
+}
+
+func TestAllFilesExcluded(t *testing.T) {
+	m := newTestMainModel(t)
+	m.config.UI.Exclude = []string{"**"}
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+
+	updated, cmd := m.Update(m.fetchFileTree())
+	m = updated.(mainModel)
+	if cmd != nil {
+		if _, quit := cmd().(tea.QuitMsg); quit {
+			t.Fatal("expected diffnav not to quit when every file is excluded")
+		}
+	}
+	view := ansi.Strip(m.View().Content)
+	if !strings.Contains(view, "files of the diff are excluded by:") || !strings.Contains(view, "ui.exclude: **") {
+		t.Fatalf("expected the view to tell why the files are missing, got:\n%s", view)
+	}
+
+	_, cmd = m.Update(keyPress("q"))
+	if cmd == nil {
+		t.Fatal("expected q to quit")
+	}
+	if _, quit := cmd().(tea.QuitMsg); !quit {
+		t.Fatal("expected q to quit")
+	}
+}
+
+func TestActionKeyDoesNotScrollDiff(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = showDiff(t, m)
+
+	m = updateMainModel(t, m, keyPress("f"))
+	if !m.filtering {
+		t.Fatal("expected f to open the filter bar")
+	}
+	if got := m.diffViewer.YOffset(); got != 0 {
+		t.Fatalf("expected the diff not to scroll as the filter opens, got row %d", got)
+	}
+}
+
+func TestSelectFromDiffKeepsScroll(t *testing.T) {
+	m := newTestMainModel(t)
+	m = updateMainModel(t, m, tea.WindowSizeMsg{Width: 100, Height: 40})
+	m = showDiff(t, m)
+	m = updateMainModel(t, m, keyPress("ctrl+d"))
+	offset := m.diffViewer.YOffset()
+	if offset == 0 {
+		t.Fatal("expected ctrl+d to scroll the diff")
+	}
+
+	m = updateMainModel(t, m, keyPress("space"))
+	if len(m.fileTree.SelectedFiles()) == 0 {
+		t.Fatal("expected space to select the node under the cursor")
+	}
+	if got := m.diffViewer.YOffset(); got != offset {
+		t.Fatalf("expected the diff to stay at row %d, got %d", offset, got)
+	}
+}
+
+// namedKeys maps the names of keys in bindings to their codes.
+var namedKeys = map[string]rune{
+	"up":        tea.KeyUp,
+	"down":      tea.KeyDown,
+	"left":      tea.KeyLeft,
+	"right":     tea.KeyRight,
+	"home":      tea.KeyHome,
+	"end":       tea.KeyEnd,
+	"pgup":      tea.KeyPgUp,
+	"pgdown":    tea.KeyPgDown,
+	"enter":     tea.KeyEnter,
+	"tab":       tea.KeyTab,
+	"esc":       tea.KeyEscape,
+	"space":     tea.KeySpace,
+	"backspace": tea.KeyBackspace,
+	"delete":    tea.KeyDelete,
+	"insert":    tea.KeyInsert,
+	"f1":        tea.KeyF1,
+	"f2":        tea.KeyF2,
+	"f3":        tea.KeyF3,
+	"f4":        tea.KeyF4,
+	"f5":        tea.KeyF5,
+	"f6":        tea.KeyF6,
+	"f7":        tea.KeyF7,
+	"f8":        tea.KeyF8,
+	"f9":        tea.KeyF9,
+	"f10":       tea.KeyF10,
+	"f11":       tea.KeyF11,
+	"f12":       tea.KeyF12,
+}
+
+// keyPress returns the press of a key named as in bindings, e.g. "ctrl+d".
+func keyPress(s string) tea.KeyPressMsg {
+	var k tea.Key
+	for {
+		mod, rest, ok := strings.Cut(s, "+")
+		if !ok || rest == "" {
+			break
+		}
+		switch mod {
+		case "ctrl":
+			k.Mod |= tea.ModCtrl
+		case "alt":
+			k.Mod |= tea.ModAlt
+		case "shift":
+			k.Mod |= tea.ModShift
+		}
+		s = rest
+	}
+
+	if code, ok := namedKeys[s]; ok {
+		k.Code = code
+		if code == tea.KeySpace {
+			k.Text = " "
+		}
+		return tea.KeyPressMsg(k)
+	}
+	k.Code = []rune(s)[0]
+	if k.Mod == 0 {
+		k.Text = s
+	}
+	return tea.KeyPressMsg(k)
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"errors"
+
+	"charm.land/bubbles/v2/key"
+	tea "charm.land/bubbletea/v2"
+	"github.com/atotto/clipboard"
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+	zone "github.com/lrstanley/bubblezone/v2"
+
+	"github.com/dlvhdr/diffnav/pkg/patch"
+)
+
+// copiedMsg is sent once text is copied to the clipboard, or failed to be.
+type copiedMsg struct {
+	err error
+}
+
+func copyText(text string) tea.Cmd {
+	return func() tea.Msg {
+		return copiedMsg{err: clipboard.WriteAll(text)}
+	}
+}
+
+// selectLines starts selecting lines of the diff, from the top of the
+// viewport.
+func (m mainModel) selectLines() mainModel {
+	if !m.diffViewer.StartSelection(-1) {
+		m.statusErr = errors.New("only the diff of a file can be selected in")
+		return m
+	}
+	m.activePanel = DiffViewerPanel
+	return m
+}
+
+func (m mainModel) visualUpdate(msg tea.KeyPressMsg) (mainModel, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Visual.Quit):
+		return m, tea.Quit
+	case key.Matches(msg, m.keys.Visual.Close):
+		m.diffViewer.ClearSelection()
+	case key.Matches(msg, m.keys.Visual.Up):
+		m.diffViewer.MoveSelection(-1)
+	case key.Matches(msg, m.keys.Visual.Down):
+		m.diffViewer.MoveSelection(1)
+	case key.Matches(msg, m.keys.Visual.CopyNew):
+		return m.copySelection(patch.NewText)
+	case key.Matches(msg, m.keys.Visual.CopyOld):
+		return m.copySelection(patch.OldText)
+	case key.Matches(msg, m.keys.Visual.CopyPatch):
+		return m.copySelection(func(file *gitdiff.File, lines []patch.Line) string {
+			if selected := patch.Select(file, lines); selected != nil {
+				return selected.String()
+			}
+			return ""
+		})
+	}
+	return m, nil
+}
+
+// copySelection copies the text format returns for the selected lines, and
+// stops selecting.
+func (m mainModel) copySelection(format func(*gitdiff.File, []patch.Line) string) (mainModel, tea.Cmd) {
+	file, lines, err := m.diffViewer.SelectedLines()
+	m.diffViewer.ClearSelection()
+	if err != nil {
+		m.statusErr = err
+		return m, nil
+	}
+	if file == nil || len(lines) == 0 {
+		m.statusErr = errors.New("no diff lines selected")
+		return m, nil
+	}
+	text := format(file, lines)
+	if text == "" {
+		m.statusErr = errors.New("nothing to copy in the selected lines")
+		return m, nil
+	}
+	return m, copyText(text)
+}
+
+// handleDiffClick drops the selection, and starts a new one from the clicked
+// row if the mouse is then dragged.
+func (m mainModel) handleDiffClick(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	m.diffViewer.ClearSelection()
+	m.draggingDiff = true
+	m.dragRow = m.diffViewer.RowAt(msg.Mouse().Y - zone.Get(zoneDiffViewer).StartY)
+	return m, nil
+}
+
+// handleDiffDrag selects the rows from the one the drag started from to the
+// one under the mouse.
+func (m mainModel) handleDiffDrag(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	row := m.diffViewer.RowAt(msg.Mouse().Y - zone.Get(zoneDiffViewer).StartY)
+	if !m.diffViewer.Selecting() {
+		if row == m.dragRow || m.dragRow < m.diffViewer.YOffset() {
+			return m, nil
+		}
+		if !m.diffViewer.StartSelection(m.dragRow) {
+			m.draggingDiff = false
+			return m, nil
+		}
+		m.activePanel = DiffViewerPanel
+	}
+	m.diffViewer.SetSelectionEnd(row)
+	return m, nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// GitTopLevel returns the root of the git repository containing the current
+// directory, falling back to the current directory outside of a repository.
+func GitTopLevel() string {
+	if root := GitRepoRoot(); root != "" {
+		return root
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return wd
+}
+
+// GitRepoRoot returns the root of the git repository containing the current
+// directory, or "" outside of a repository.
+func GitRepoRoot() string {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GitBlob returns the contents of the blob with the given, possibly
+// abbreviated, object id.
+func GitBlob(root, oid string) ([]byte, error) {
+	cmd := exec.Command("git", "cat-file", "blob", oid)
+	cmd.Dir = root
+	return cmd.Output()
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+var (
+	globCacheMu sync.Mutex
+	globCache   = map[string]*regexp.Regexp{}
+)
+
+// MatchGlob reports whether name matches the gitignore-like glob pattern.
+// Patterns without a slash are matched against the base name at any depth,
+// "**" matches across directories, and a trailing slash matches everything
+// below that directory.
+func MatchGlob(pattern, name string) bool {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return false
+	}
+	name = strings.TrimPrefix(name, "/")
+
+	if strings.HasSuffix(pattern, "/") {
+		pattern += "**"
+	}
+	if !strings.Contains(strings.TrimSuffix(pattern, "/**"), "/") {
+		if strings.HasSuffix(pattern, "/**") {
+			pattern = "**/" + pattern
+		} else if ok, _ := path.Match(pattern, path.Base(name)); ok {
+			return true
+		}
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	return globRegexp(pattern).MatchString(name)
+}
+
+func globRegexp(pattern string) *regexp.Regexp {
+	globCacheMu.Lock()
+	defer globCacheMu.Unlock()
+	if re, ok := globCache[pattern]; ok {
+		return re
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch c {
+		case '*':
+			if i+1 < len(pattern) && pattern[i+1] == '*' {
+				i++
+				if i+1 < len(pattern) && pattern[i+1] == '/' {
+					// "**/" matches zero or more directories.
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+			} else {
+				sb.WriteString("[^/]*")
+			}
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i:], ']')
+			if end < 0 {
+				sb.WriteString(regexp.QuoteMeta(string(c)))
+				continue
+			}
+			class := pattern[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	if err != nil {
+		re = regexp.MustCompile("^" + regexp.QuoteMeta(pattern) + "$")
+	}
+	globCache[pattern] = re
+	return re
+}
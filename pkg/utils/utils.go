@@ -3,6 +3,7 @@ package utils
 import (
 	"strings"
 
+	"github.com/charmbracelet/x/ansi"
 	"github.com/muesli/reflow/truncate"
 )
 
@@ -17,6 +18,29 @@ func TruncateString(s string, max int) string {
 	return truncate.StringWithTail(s, uint(max), "…")
 }
 
+// TruncateMiddle shortens s to max cells by replacing its middle with an
+// ellipsis, keeping both the start and the end (e.g. the file name) visible.
+func TruncateMiddle(s string, max int) string {
+	width := ansi.StringWidth(s)
+	if width <= max {
+		return s
+	}
+	if max <= 1 {
+		return TruncateString(s, max)
+	}
+	tail := (max - 1) / 2
+	head := max - 1 - tail
+	// A wide character cut in two is kept at the start of the end, so more is
+	// cut until the end fits.
+	end := ""
+	for cut := width - tail; cut <= width; cut++ {
+		if end = ansi.TruncateLeft(s, cut, ""); ansi.StringWidth(end) <= tail {
+			break
+		}
+	}
+	return ansi.Truncate(s, head, "") + "…" + end
+}
+
 func RemoveReset(s string) string {
 	// Remove ANSI reset codes
 	return strings.ReplaceAll(s, "\x1b[m", "")
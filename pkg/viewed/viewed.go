@@ -0,0 +1,195 @@
+// Package viewed persists which file patches were marked as viewed, so that
+// review progress survives restarts and rebases.
+package viewed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+// maxAge is how long a viewed mark is kept before it's pruned.
+const maxAge = 90 * 24 * time.Hour
+
+// lockTimeout is how long Save waits for another session to finish saving.
+// A lock older than lockStale is left over by a session that crashed.
+const (
+	lockTimeout = 2 * time.Second
+	lockStale   = 10 * time.Second
+)
+
+// Store maps patch hashes to the time they were marked as viewed. Patches are
+// hashed with their paths, so the same change in two files is tracked
+// separately, and any change to their lines un-marks them.
+type Store struct {
+	path    string
+	Patches map[string]time.Time `json:"patches"`
+
+	// changes are the marks set (or un-marked, when zero) since the store
+	// was loaded, which Save applies to the store on disk.
+	changes map[string]time.Time
+}
+
+// Load reads the store from the state directory. A missing or corrupt store
+// results in an empty one.
+func Load() *Store {
+	s := &Store{path: storePath(), changes: map[string]time.Time{}}
+	s.Patches = readPatches(s.path)
+	return s
+}
+
+// readPatches reads the marks of the store at path, without the expired ones.
+func readPatches(path string) map[string]time.Time {
+	var s Store
+	if path != "" {
+		if data, err := os.ReadFile(path); err == nil {
+			_ = json.Unmarshal(data, &s)
+		}
+	}
+	if s.Patches == nil {
+		return map[string]time.Time{}
+	}
+	for hash, t := range s.Patches {
+		if time.Since(t) > maxAge {
+			delete(s.Patches, hash)
+		}
+	}
+	return s.Patches
+}
+
+// IsViewed returns whether the file's current patch was marked as viewed.
+func (s *Store) IsViewed(file *gitdiff.File) bool {
+	_, ok := s.Patches[Hash(file)]
+	return ok
+}
+
+// Set marks or un-marks the file's current patch as viewed.
+func (s *Store) Set(file *gitdiff.File, viewed bool) {
+	hash := Hash(file)
+	if viewed {
+		s.Patches[hash] = time.Now()
+		s.changes[hash] = s.Patches[hash]
+	} else {
+		delete(s.Patches, hash)
+		s.changes[hash] = time.Time{}
+	}
+}
+
+// Save writes the store to disk. The store is read again first and only the
+// marks changed since it was loaded are applied, so that sessions running
+// side by side keep each other's marks.
+func (s *Store) Save() error {
+	if s.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	unlock, err := lock(s.path + ".lock")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	patches := readPatches(s.path)
+	for hash, t := range s.changes {
+		if t.IsZero() {
+			delete(patches, hash)
+		} else {
+			patches[hash] = t
+		}
+	}
+	data, err := json.Marshal(Store{Patches: patches})
+	if err != nil {
+		return err
+	}
+
+	// The store is replaced by a rename so that it's never read half written.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".viewed-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.Patches = patches
+	s.changes = map[string]time.Time{}
+	return nil
+}
+
+// lock creates the lock file at path, waiting for another session holding it
+// to remove it. It returns a function removing it.
+func lock(path string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("viewed store is locked by %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Hash returns the key of the file's patch in the store: a hash of its paths
+// and of the lines of its hunks, without their positions or the blob ids, so
+// that a rebase moving the change within the file keeps its mark.
+// Binary patches, which have no lines, are hashed with their blob ids.
+func Hash(file *gitdiff.File) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00", file.OldName, file.NewName)
+	for _, frag := range file.TextFragments {
+		io.WriteString(h, "@@\x00")
+		for _, line := range frag.Lines {
+			fmt.Fprintf(h, "%s%s", line.Op, line.Line)
+		}
+	}
+	if file.IsBinary {
+		fmt.Fprintf(h, "%s\x00%s\x00", file.OldOIDPrefix, file.NewOIDPrefix)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func storePath() string {
+	if dir := os.Getenv("DIFFNAV_STATE_DIR"); dir != "" {
+		return filepath.Join(dir, "viewed.json")
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "diffnav", "viewed.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", "diffnav", "viewed.json")
+}
@@ -0,0 +1,98 @@
+package viewed
+
+import (
+	"testing"
+
+	"github.com/bluekeyes/go-gitdiff/gitdiff"
+)
+
+func newFile(line string) *gitdiff.File {
+	return &gitdiff.File{
+		OldName: "main.go",
+		NewName: "main.go",
+		TextFragments: []*gitdiff.TextFragment{{
+			OldPosition: 1,
+			NewPosition: 1,
+			NewLines:    1,
+			LinesAdded:  1,
+			Lines:       []gitdiff.Line{{Op: gitdiff.OpAdd, Line: line + "\n"}},
+		}},
+	}
+}
+
+func TestStorePersistsViewedPatches(t *testing.T) {
+	t.Setenv("DIFFNAV_STATE_DIR", t.TempDir())
+
+	file := newFile("package main")
+	s := Load()
+	s.Set(file, true)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := Load()
+	if !reloaded.IsViewed(file) {
+		t.Fatal("expected the patch to still be viewed after reloading the store")
+	}
+	if reloaded.IsViewed(newFile("package other")) {
+		t.Fatal("expected a changed patch to no longer be viewed")
+	}
+
+	reloaded.Set(file, false)
+	if reloaded.IsViewed(file) {
+		t.Fatal("expected the patch to be un-marked")
+	}
+}
+
+func TestSaveKeepsOtherSessionsMarks(t *testing.T) {
+	t.Setenv("DIFFNAV_STATE_DIR", t.TempDir())
+
+	a, b, c := newFile("package a"), newFile("package b"), newFile("package c")
+	first := Load()
+	first.Set(c, true)
+	if err := first.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two sessions loaded the store before either of them saved.
+	one, two := Load(), Load()
+	one.Set(a, true)
+	two.Set(b, true)
+	two.Set(c, false)
+	if err := one.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if err := two.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := Load()
+	if !reloaded.IsViewed(a) || !reloaded.IsViewed(b) {
+		t.Fatal("expected the marks of both sessions to be kept")
+	}
+	if reloaded.IsViewed(c) {
+		t.Fatal("expected the un-marked patch to stay un-marked")
+	}
+	if !two.IsViewed(a) {
+		t.Fatal("expected saving to pick up the marks of the other session")
+	}
+}
+
+func TestHashIgnoresPositionsAndBlobs(t *testing.T) {
+	file := newFile("package main")
+	moved := newFile("package main")
+	moved.OldOIDPrefix, moved.NewOIDPrefix = "1234567", "89abcde"
+	moved.TextFragments[0].OldPosition, moved.TextFragments[0].NewPosition = 40, 42
+	if Hash(moved) != Hash(file) {
+		t.Fatal("expected the same lines at another position to keep their hash")
+	}
+
+	renamed := newFile("package main")
+	renamed.NewName = "other.go"
+	if Hash(renamed) == Hash(file) {
+		t.Fatal("expected the paths to be hashed")
+	}
+	if Hash(newFile("package other")) == Hash(file) {
+		t.Fatal("expected the lines to be hashed")
+	}
+}